@@ -13,6 +13,8 @@ import (
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/hub"
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/repository"
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/service"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/broker"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/presence"
 	redispkg "github.com/dmehra2102/go-realtime-chat/chat-service/pkg/redis"
 	"github.com/dmehra2102/go-realtime-chat/shared/pkg/config"
 	"github.com/dmehra2102/go-realtime-chat/shared/pkg/database"
@@ -31,6 +33,9 @@ func main() {
 	appLogger := logger.NewLogger("chat-service")
 
 	cfg := config.LoadConfig()
+	if err := cfg.Validate(); err != nil {
+		appLogger.Fatal("Invalid configuration", "error", err)
+	}
 
 	db, err := database.NewPostgresConnection(cfg.Database)
 	if err != nil {
@@ -41,35 +46,83 @@ func main() {
 		appLogger.Fatal("Failed to migrate database", "error", err)
 	}
 
-	redisClient := redis.NewClient(&redis.Options{
-		Addr:     cfg.RedisAddr,
-		Password: cfg.RedisPassword,
-		DB:       0,
-	})
-
-	ctx := context.Background()
-	if err := redisClient.Ping(ctx).Err(); err != nil {
-		appLogger.Fatal("Failed to connect to Redis", "error", err)
+	// The in-memory broker only fans out within this process, so it needs no Redis.
+	var redisClient *redis.Client
+	if cfg.BrokerMode != broker.ModeMemory {
+		redisClient = redis.NewClient(&redis.Options{
+			Addr:     cfg.RedisAddr,
+			Password: cfg.RedisPassword,
+			DB:       0,
+		})
+
+		if err := redisClient.Ping(context.Background()).Err(); err != nil {
+			appLogger.Fatal("Failed to connect to Redis", "error", err)
+		}
 	}
 
 	roomRepo := repository.NewRoomRepository(db.DB)
 	messageRepo := repository.NewMessageRepository(db.DB)
+	reactionRepo := repository.NewReactionRepository(db.DB)
+	inviteRepo := repository.NewInviteRepository(db.DB)
+	moderationRepo := repository.NewModerationRepository(db.DB)
+
+	var msgBroker broker.Broker
+	var tracker presence.Tracker
+	switch cfg.BrokerMode {
+	case broker.ModeMemory:
+		msgBroker = broker.NewMemoryBroker()
+		tracker = presence.NewMemoryTracker(presence.DefaultTTL)
+	case broker.ModeStreams:
+		msgBroker = redispkg.NewRedisStreams(redisClient, cfg.NodeID, cfg.StreamMaxLen, appLogger)
+		tracker = presence.NewRedisTracker(redisClient, presence.DefaultTTL)
+	case broker.ModePubSub:
+		msgBroker = redispkg.NewRedisPubSub(redisClient, appLogger)
+		tracker = presence.NewRedisTracker(redisClient, presence.DefaultTTL)
+	default:
+		appLogger.Fatal("Unknown broker mode", "mode", cfg.BrokerMode)
+	}
+	appLogger.Info("Using message broker", "mode", cfg.BrokerMode)
 
-	chatService := service.NewChatService(roomRepo, messageRepo)
-
-	redisPubSub := redispkg.NewRedisPubSub(redisClient, appLogger)
+	chatService := service.NewChatService(roomRepo, messageRepo, reactionRepo, inviteRepo, moderationRepo, tracker, cfg.MaxPinsPerRoom, appLogger)
 
-	chatHub := hub.NewHub(redisPubSub, chatService, appLogger)
+	chatHub := hub.NewHub(msgBroker, tracker, chatService, cfg.NodeID, appLogger)
 	go chatHub.Run()
 
-	wsHandler := handler.NewWebSocketHandler(chatHub, chatService, cfg.JWTSecret, appLogger)
+	jobCtx, stopJobs := context.WithCancel(context.Background())
+	defer stopJobs()
+	go runRetentionJob(jobCtx, chatService, cfg.MessageRetentionGrace, appLogger)
+
+	wsHandler := handler.NewWebSocketHandler(chatHub, chatService, tracker, cfg.JWTSecret, appLogger)
 
 	router := mux.NewRouter()
 	router.HandleFunc("/health", healthCheckHandler).Methods("GET")
 	router.HandleFunc("/ws", wsHandler.HandleWebSocket)
 	router.HandleFunc("/api/rooms", wsHandler.CreateRoom).Methods("POST")
 	router.HandleFunc("/api/rooms", wsHandler.ListRooms).Methods("GET")
+	router.HandleFunc("/api/rooms/{roomId}", wsHandler.UpdateRoom).Methods("PATCH")
+	router.HandleFunc("/api/rooms/{roomId}", wsHandler.DeleteRoom).Methods("DELETE")
+	router.HandleFunc("/api/rooms/{roomId}/archive", wsHandler.ArchiveRoom).Methods("POST")
+	router.HandleFunc("/api/rooms/{roomId}/archive", wsHandler.UnarchiveRoom).Methods("DELETE")
+	router.HandleFunc("/api/rooms/{roomId}/transfer", wsHandler.TransferOwnership).Methods("POST")
+	router.HandleFunc("/api/dms", wsHandler.OpenDirectMessage).Methods("POST")
+	router.HandleFunc("/api/me/unread", wsHandler.GetUnreadCounts).Methods("GET")
+	router.HandleFunc("/api/me/mentions", wsHandler.GetMentions).Methods("GET")
+	router.HandleFunc("/api/search/messages", wsHandler.SearchMessages).Methods("GET")
 	router.HandleFunc("/api/rooms/{roomId}/messages", wsHandler.GetRoomMessages).Methods("GET")
+	router.HandleFunc("/api/rooms/{roomId}/messages/{id}", wsHandler.EditMessage).Methods("PATCH")
+	router.HandleFunc("/api/rooms/{roomId}/messages/{id}", wsHandler.DeleteMessage).Methods("DELETE")
+	router.HandleFunc("/api/rooms/{roomId}/threads/{messageId}", wsHandler.GetThread).Methods("GET")
+	router.HandleFunc("/api/rooms/{roomId}/presence", wsHandler.GetRoomPresence).Methods("GET")
+	router.HandleFunc("/api/rooms/{roomId}/export", wsHandler.ExportRoom).Methods("GET")
+	router.HandleFunc("/api/rooms/{roomId}/pins", wsHandler.GetPins).Methods("GET")
+	router.HandleFunc("/api/rooms/{roomId}/pins/{messageId}", wsHandler.PinMessage).Methods("PUT")
+	router.HandleFunc("/api/rooms/{roomId}/pins/{messageId}", wsHandler.UnpinMessage).Methods("DELETE")
+	router.HandleFunc("/api/rooms/{roomId}/invitations", wsHandler.InviteUser).Methods("POST")
+	router.HandleFunc("/api/rooms/{roomId}/invites", wsHandler.CreateInviteLink).Methods("POST")
+	router.HandleFunc("/api/invites/{token}/accept", wsHandler.AcceptInvite).Methods("POST")
+	router.HandleFunc("/api/rooms/{roomId}/members/{userId}/promote", wsHandler.PromoteMember).Methods("POST")
+	router.HandleFunc("/api/rooms/{roomId}/members/{userId}/demote", wsHandler.DemoteMember).Methods("POST")
+	router.HandleFunc("/api/rooms/{roomId}/members/{userId}/{action:kick|ban|mute}", wsHandler.ModerateMember).Methods("POST")
 
 	srv := &http.Server{
 		Addr:         ":" + cfg.ChatServicePort,
@@ -93,6 +146,8 @@ func main() {
 
 	appLogger.Info("Shutting down chat service...")
 
+	stopJobs()
+
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
 	defer cancel()
 
@@ -100,8 +155,13 @@ func main() {
 		appLogger.Error("Server forced to shutdown", "error", err)
 	}
 
-	if err := redisClient.Close(); err != nil {
-		appLogger.Error("Failed to close Redis connection", "error", err)
+	// Websocket connections are hijacked, so the server does not close them.
+	chatHub.Shutdown()
+
+	if redisClient != nil {
+		if err := redisClient.Close(); err != nil {
+			appLogger.Error("Failed to close Redis connection", "error", err)
+		}
 	}
 
 	sqlDB, _ := db.DB.DB()
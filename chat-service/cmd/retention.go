@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/service"
+	"github.com/dmehra2102/go-realtime-chat/shared/pkg/logger"
+)
+
+const retentionInterval = time.Hour
+
+// runRetentionJob purges the revisions of deleted messages once they have
+// been tombstones for longer than grace. The tombstones themselves are kept.
+// Every node runs it; the purge is idempotent.
+func runRetentionJob(ctx context.Context, chatService service.ChatService, grace time.Duration, appLogger *logger.Logger) {
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		purged, err := chatService.PurgeDeletedMessages(time.Now().Add(-grace))
+		if err != nil {
+			appLogger.Error("Failed to purge deleted messages", "error", err)
+		} else if purged > 0 {
+			appLogger.Info("Purged deleted message revisions", "count", purged)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
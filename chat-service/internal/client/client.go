@@ -3,6 +3,7 @@ package client
 import (
 	"context"
 	"encoding/json"
+	"sync"
 	"time"
 
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
@@ -26,16 +27,18 @@ type Client struct {
 	Hub      Hub
 	Conn     *websocket.Conn
 	Send     chan []byte
-	Rooms    map[string]bool
+	Rooms    map[string]bool // written only by the hub
 	Logger   *logger.Logger
 	ctx      context.Context
 	cancel   context.CancelFunc
+	once     sync.Once
 }
 
 type Hub interface {
 	Register(client *Client)
 	Unregister(client *Client)
-	Broadcast(message *models.WebSocketMessage)
+	Broadcast(sender *Client, message *models.WebSocketMessage)
+	RefreshPresence(client *Client)
 }
 
 func NewClient(userID, username string, hub Hub, conn *websocket.Conn, logger *logger.Logger) *Client {
@@ -55,9 +58,10 @@ func NewClient(userID, username string, hub Hub, conn *websocket.Conn, logger *l
 	}
 }
 
+// Close is safe to call more than once. Send is left open so a concurrent
+// SendMessage can never write to a closed channel; WritePump exits on ctx.
 func (c *Client) Close() {
-	c.cancel()
-	close(c.Send)
+	c.once.Do(c.cancel)
 }
 
 func (c *Client) ReadPump() {
@@ -90,15 +94,12 @@ func (c *Client) ReadPump() {
 			msg.UserID = c.UserID
 			msg.Username = c.Username
 
+			// Room membership in c.Rooms is owned by the hub.
 			switch msg.Type {
-			case "join":
-				c.Rooms[msg.RoomID] = true
-				c.Hub.Broadcast(&msg)
-			case "leave":
-				delete(c.Rooms, msg.RoomID)
-				c.Hub.Broadcast(&msg)
-			case "message":
-				c.Hub.Broadcast(&msg)
+			case "join", "leave", "message", "resume", "typing_start", "typing_stop", "presence",
+				"message_edit", "message_delete", "reaction_add", "reaction_remove",
+				"kick", "ban", "mute", "read":
+				c.Hub.Broadcast(c, &msg)
 			default:
 				c.Logger.Warn("Unknown message type", "type", msg.Type)
 				continue
@@ -148,6 +149,7 @@ func (c *Client) WritePump() {
 			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				return
 			}
+			c.Hub.RefreshPresence(c)
 		}
 	}
 }
@@ -159,6 +161,10 @@ func (c *Client) SendMessage(msg *models.WebSocketMessage) {
 		return
 	}
 
+	if c.ctx.Err() != nil {
+		return
+	}
+
 	select {
 	case c.Send <- data:
 	case <-c.ctx.Done():
@@ -12,7 +12,7 @@ CREATE TABLE IF NOT EXISTS room_participants (
     room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
     user_id UUID NOT NULL,
     joined_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
-    UNQIUE(room_id, user_id)
+    UNIQUE(room_id, user_id)
 );
 
 CREATE TABLE IF NOT EXISTS messages (
@@ -0,0 +1,3 @@
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS uq_messages_user_client_msg_id;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS client_msg_id;
@@ -0,0 +1,3 @@
+ALTER TABLE messages ADD COLUMN IF NOT EXISTS client_msg_id VARCHAR(64);
+
+ALTER TABLE messages ADD CONSTRAINT uq_messages_user_client_msg_id UNIQUE (user_id, client_msg_id);
@@ -0,0 +1,5 @@
+ALTER TABLE messages DROP CONSTRAINT IF EXISTS uq_messages_room_seq;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS seq;
+
+ALTER TABLE rooms DROP COLUMN IF EXISTS last_seq;
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_message_revisions_message_id;
+
+DROP TABLE IF EXISTS message_revisions;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS revision_count;
+ALTER TABLE messages DROP COLUMN IF EXISTS edited_at;
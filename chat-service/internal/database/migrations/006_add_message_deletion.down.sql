@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_messages_deleted_at;
+
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_by;
+ALTER TABLE messages DROP COLUMN IF EXISTS deleted_at;
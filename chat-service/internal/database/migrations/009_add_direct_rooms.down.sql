@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_room_participants_user_id;
+
+ALTER TABLE rooms DROP CONSTRAINT IF EXISTS uq_rooms_dm_key;
+
+ALTER TABLE rooms DROP COLUMN IF EXISTS dm_key;
+ALTER TABLE rooms DROP COLUMN IF EXISTS type;
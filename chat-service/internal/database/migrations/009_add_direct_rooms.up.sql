@@ -0,0 +1,6 @@
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS type VARCHAR(16) NOT NULL DEFAULT 'group';
+ALTER TABLE rooms ADD COLUMN IF NOT EXISTS dm_key VARCHAR(80);
+
+ALTER TABLE rooms ADD CONSTRAINT uq_rooms_dm_key UNIQUE (dm_key);
+
+CREATE INDEX IF NOT EXISTS idx_room_participants_user_id ON room_participants(user_id);
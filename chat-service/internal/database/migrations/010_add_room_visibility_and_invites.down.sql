@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_room_invite_links_room_id;
+
+DROP TABLE IF EXISTS room_invite_links;
+DROP TABLE IF EXISTS room_invitations;
+
+ALTER TABLE rooms DROP COLUMN IF EXISTS visibility;
@@ -0,0 +1 @@
+ALTER TABLE room_participants DROP COLUMN IF EXISTS role;
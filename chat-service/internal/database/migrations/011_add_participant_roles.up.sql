@@ -0,0 +1,5 @@
+ALTER TABLE room_participants ADD COLUMN IF NOT EXISTS role VARCHAR(16) NOT NULL DEFAULT 'member';
+
+UPDATE room_participants p SET role = 'owner'
+FROM rooms r
+WHERE p.room_id = r.id AND p.user_id = r.created_by AND r.type = 'group';
@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_moderation_logs_room_created;
+
+DROP TABLE IF EXISTS moderation_logs;
+DROP TABLE IF EXISTS room_bans;
+
+ALTER TABLE room_participants DROP COLUMN IF EXISTS muted_until;
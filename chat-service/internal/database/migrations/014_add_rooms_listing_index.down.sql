@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_rooms_visibility_created;
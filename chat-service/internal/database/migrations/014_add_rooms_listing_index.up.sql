@@ -0,0 +1 @@
+CREATE INDEX IF NOT EXISTS idx_rooms_visibility_created ON rooms(visibility, created_at DESC, id DESC);
@@ -0,0 +1,3 @@
+ALTER TABLE room_participants DROP COLUMN IF EXISTS last_read_at;
+ALTER TABLE room_participants DROP COLUMN IF EXISTS last_read_message_id;
+ALTER TABLE room_participants DROP COLUMN IF EXISTS last_read_seq;
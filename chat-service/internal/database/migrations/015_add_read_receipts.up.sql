@@ -0,0 +1,9 @@
+ALTER TABLE room_participants ADD COLUMN IF NOT EXISTS last_read_seq BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE room_participants ADD COLUMN IF NOT EXISTS last_read_message_id UUID;
+ALTER TABLE room_participants ADD COLUMN IF NOT EXISTS last_read_at TIMESTAMP;
+
+-- Start everyone off with nothing unread rather than their whole history.
+UPDATE room_participants rp
+SET last_read_seq = r.last_seq
+FROM rooms r
+WHERE rp.room_id = r.id;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_room_pins_room_created;
+
+DROP TABLE IF EXISTS room_pins;
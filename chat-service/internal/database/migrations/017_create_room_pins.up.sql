@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS room_pins (
+    room_id UUID NOT NULL REFERENCES rooms(id) ON DELETE CASCADE,
+    message_id UUID NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+    pinned_by UUID NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (room_id, message_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_room_pins_room_created ON room_pins(room_id, created_at DESC);
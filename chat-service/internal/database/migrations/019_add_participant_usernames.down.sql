@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_room_participants_room_username;
+ALTER TABLE room_participants DROP COLUMN IF EXISTS username;
@@ -0,0 +1,15 @@
+ALTER TABLE room_participants ADD COLUMN IF NOT EXISTS username VARCHAR(50) NOT NULL DEFAULT '';
+
+-- Mentions resolve usernames from here rather than from the auth service's
+-- users table. Existing participants get the name they last posted under;
+-- the rest are filled in the next time they join.
+UPDATE room_participants rp
+SET username = m.username
+FROM (
+    SELECT DISTINCT ON (user_id) user_id, username
+    FROM messages
+    ORDER BY user_id, created_at DESC
+) m
+WHERE rp.user_id = m.user_id AND rp.username = '';
+
+CREATE INDEX IF NOT EXISTS idx_room_participants_room_username ON room_participants(room_id, username);
@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+)
+
+var csvColumns = []string{
+	"seq", "id", "timestamp", "author_id", "author", "parent_id",
+	"content", "edited_at", "deleted", "deleted_at", "deleted_by",
+}
+
+type csvWriter struct {
+	w *csv.Writer
+}
+
+func newCSVWriter(w io.Writer) Writer {
+	return &csvWriter{w: csv.NewWriter(w)}
+}
+
+func (c *csvWriter) Begin(room *models.Room, query *models.ExportQuery) error {
+	return c.w.Write(csvColumns)
+}
+
+func (c *csvWriter) WriteMessage(message *models.Message) error {
+	rec := newRecord(message)
+	return c.w.Write([]string{
+		strconv.FormatInt(rec.Seq, 10), rec.ID, rec.Timestamp, rec.AuthorID, csvText(rec.Author), rec.ParentID,
+		csvText(rec.Content), rec.EditedAt, strconv.FormatBool(rec.Deleted), rec.DeletedAt, rec.DeletedBy,
+	})
+}
+
+// csvText keeps user text from being run as a formula when the export is
+// opened in a spreadsheet, which treats cells starting with these characters
+// as one. A leading ' is shown as text and not part of the value.
+func csvText(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+func (c *csvWriter) Flush() error {
+	c.w.Flush()
+	return c.w.Error()
+}
+
+func (c *csvWriter) End() error {
+	return c.Flush()
+}
@@ -0,0 +1,138 @@
+// Package export renders room transcripts. Every format is built from the
+// same record, so authors, timestamps, edits and deletions read the same way
+// whichever format is chosen.
+package export
+
+import (
+	"io"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+)
+
+// DeletedPlaceholder stands in for the content of a deleted message.
+const DeletedPlaceholder = "[message deleted]"
+
+// Writer streams a transcript: Begin once, WriteMessage for each message in
+// order, then End. Flush pushes anything buffered to the underlying writer.
+type Writer interface {
+	Begin(room *models.Room, query *models.ExportQuery) error
+	WriteMessage(message *models.Message) error
+	Flush() error
+	End() error
+}
+
+// Format describes one transcript format.
+type Format struct {
+	Name        string
+	ContentType string
+	Extension   string
+	newWriter   func(w io.Writer) Writer
+}
+
+var formats = map[string]Format{
+	"json": {Name: "json", ContentType: "application/json", Extension: "json", newWriter: newJSONWriter},
+	"csv":  {Name: "csv", ContentType: "text/csv; charset=utf-8", Extension: "csv", newWriter: newCSVWriter},
+	"md":   {Name: "md", ContentType: "text/markdown; charset=utf-8", Extension: "md", newWriter: newMarkdownWriter},
+	"html": {Name: "html", ContentType: "text/html; charset=utf-8", Extension: "html", newWriter: newHTMLWriter},
+}
+
+// Lookup returns the format called name.
+func Lookup(name string) (Format, bool) {
+	format, ok := formats[name]
+	return format, ok
+}
+
+func (f Format) NewWriter(w io.Writer) Writer {
+	return f.newWriter(w)
+}
+
+// header describes the export as a whole.
+type header struct {
+	RoomID     string `json:"room_id"`
+	RoomName   string `json:"room_name"`
+	From       string `json:"from,omitempty"`
+	To         string `json:"to,omitempty"`
+	ExportedAt string `json:"exported_at"`
+}
+
+func newHeader(room *models.Room, query *models.ExportQuery) header {
+	now := time.Now()
+	return header{
+		RoomID:     room.ID.String(),
+		RoomName:   room.Name,
+		From:       formatTime(query.From),
+		To:         formatTime(query.To),
+		ExportedAt: formatTime(&now),
+	}
+}
+
+// record is a message as every format renders it. Times are RFC 3339 in
+// UTC and empty when unset.
+type record struct {
+	Seq       int64  `json:"seq"`
+	ID        string `json:"id"`
+	Timestamp string `json:"timestamp"`
+	AuthorID  string `json:"author_id"`
+	Author    string `json:"author"`
+	ParentID  string `json:"parent_id,omitempty"`
+	Content   string `json:"content"`
+	EditedAt  string `json:"edited_at,omitempty"`
+	Deleted   bool   `json:"deleted"`
+	DeletedAt string `json:"deleted_at,omitempty"`
+	DeletedBy string `json:"deleted_by,omitempty"`
+}
+
+func newRecord(m *models.Message) record {
+	rec := record{
+		Seq:       m.Seq,
+		ID:        m.ID.String(),
+		Timestamp: formatTime(&m.CreatedAt),
+		AuthorID:  m.UserID.String(),
+		Author:    m.Username,
+		Content:   m.Content,
+		EditedAt:  formatTime(m.EditedAt),
+	}
+
+	if m.ParentID != nil {
+		rec.ParentID = m.ParentID.String()
+	}
+
+	if m.DeletedAt != nil {
+		rec.Deleted = true
+		rec.Content = DeletedPlaceholder
+		rec.DeletedAt = formatTime(m.DeletedAt)
+		if m.DeletedBy != nil {
+			rec.DeletedBy = m.DeletedBy.String()
+		}
+	}
+
+	return rec
+}
+
+// notes lists what the text formats print under a message; the JSON and CSV
+// formats carry the same facts as fields.
+func (r record) notes() []string {
+	var notes []string
+	if r.ParentID != "" {
+		notes = append(notes, "reply to "+r.ParentID)
+	}
+	if r.EditedAt != "" {
+		notes = append(notes, "edited "+r.EditedAt)
+	}
+	if r.Deleted {
+		note := "deleted " + r.DeletedAt
+		if r.DeletedBy != "" {
+			note += " by " + r.DeletedBy
+		}
+		notes = append(notes, note)
+	}
+	return notes
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
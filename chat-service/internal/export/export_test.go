@@ -0,0 +1,237 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
+)
+
+const hostile = "a, \"quoted\" <b>bold</b> *star* _under_ [link](x) # | `code`\nsecond line & more"
+
+var exportTime = time.Date(2026, 1, 2, 3, 4, 5, 0, time.FixedZone("CET", 3600))
+
+func render(t *testing.T, format string, messages ...*models.Message) string {
+	t.Helper()
+
+	f, ok := Lookup(format)
+	if !ok {
+		t.Fatalf("Lookup(%q) failed", format)
+	}
+
+	var buf bytes.Buffer
+	w := f.NewWriter(&buf)
+	room := &models.Room{ID: uuid.New(), Name: "<team> & *friends*"}
+
+	if err := w.Begin(room, &models.ExportQuery{}); err != nil {
+		t.Fatalf("Begin() error = %v", err)
+	}
+	for _, m := range messages {
+		if err := w.WriteMessage(m); err != nil {
+			t.Fatalf("WriteMessage() error = %v", err)
+		}
+	}
+	if err := w.End(); err != nil {
+		t.Fatalf("End() error = %v", err)
+	}
+	return buf.String()
+}
+
+func newMessage(content string) *models.Message {
+	return &models.Message{
+		ID:        uuid.New(),
+		RoomID:    uuid.New(),
+		UserID:    uuid.New(),
+		Username:  "alice",
+		Seq:       7,
+		Content:   content,
+		CreatedAt: exportTime,
+	}
+}
+
+func deletedMessage() *models.Message {
+	m := newMessage("")
+	deletedBy := uuid.New()
+	m.DeletedAt = &exportTime
+	m.DeletedBy = &deletedBy
+	return m
+}
+
+func TestLookup(t *testing.T) {
+	for _, name := range []string{"json", "csv", "md", "html"} {
+		if _, ok := Lookup(name); !ok {
+			t.Errorf("Lookup(%q) failed", name)
+		}
+	}
+	for _, name := range []string{"", "xml", "JSON"} {
+		if _, ok := Lookup(name); ok {
+			t.Errorf("Lookup(%q) succeeded, want failure", name)
+		}
+	}
+}
+
+func TestCSVRoundTripsContent(t *testing.T) {
+	out := render(t, "csv", newMessage(hostile), deletedMessage())
+
+	records, err := csv.NewReader(strings.NewReader(out)).ReadAll()
+	if err != nil {
+		t.Fatalf("output is not valid CSV: %v", err)
+	}
+	if len(records) != 3 {
+		t.Fatalf("got %d records, want header and 2 rows", len(records))
+	}
+
+	column := make(map[string]int)
+	for i, name := range records[0] {
+		column[name] = i
+	}
+
+	row := records[1]
+	if got := row[column["content"]]; got != hostile {
+		t.Errorf("content = %q, want %q", got, hostile)
+	}
+	if got := row[column["timestamp"]]; got != "2026-01-02T02:04:05Z" {
+		t.Errorf("timestamp = %q, want UTC RFC 3339", got)
+	}
+
+	deleted := records[2]
+	if got := deleted[column["content"]]; got != DeletedPlaceholder {
+		t.Errorf("deleted content = %q, want %q", got, DeletedPlaceholder)
+	}
+	if got := deleted[column["deleted"]]; got != "true" {
+		t.Errorf("deleted = %q, want true", got)
+	}
+}
+
+func TestCSVNeutralisesFormulas(t *testing.T) {
+	tests := []struct {
+		content string
+		want    string
+	}{
+		{content: "=HYPERLINK(\"http://evil\")", want: "'=HYPERLINK(\"http://evil\")"},
+		{content: "+1+1", want: "'+1+1"},
+		{content: "-2+3", want: "'-2+3"},
+		{content: "@alice see above", want: "'@alice see above"},
+		{content: "\t=1", want: "'\t=1"},
+		{content: "\r=1", want: "'\r=1"},
+		{content: "email me at a@b.c", want: "email me at a@b.c"},
+		{content: "1+1=2", want: "1+1=2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.content, func(t *testing.T) {
+			m := newMessage(tt.content)
+			m.Username = "=cmd"
+
+			records, err := csv.NewReader(strings.NewReader(render(t, "csv", m))).ReadAll()
+			if err != nil {
+				t.Fatalf("output is not valid CSV: %v", err)
+			}
+
+			column := make(map[string]int)
+			for i, name := range records[0] {
+				column[name] = i
+			}
+			if got := records[1][column["content"]]; got != tt.want {
+				t.Errorf("content = %q, want %q", got, tt.want)
+			}
+			if got := records[1][column["author"]]; got != "'=cmd" {
+				t.Errorf("author = %q, want %q", got, "'=cmd")
+			}
+		})
+	}
+}
+
+func TestMarkdownEscapesContent(t *testing.T) {
+	out := render(t, "md", newMessage(hostile))
+
+	for _, want := range []string{
+		`\<b\>bold\</b\>`,
+		`\*star\*`,
+		`\_under\_`,
+		`\[link\](x)`,
+		`\# \|`,
+		"\\`code\\`",
+		"  \nsecond line",
+		`# \<team\> & \*friends\*`,
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "<b>") {
+		t.Errorf("output contains unescaped markup:\n%s", out)
+	}
+}
+
+func TestHTMLEscapesContent(t *testing.T) {
+	out := render(t, "html", newMessage(hostile+"<script>alert(1)</script>"))
+
+	for _, unwanted := range []string{"<script>", "<b>bold", "<team>"} {
+		if strings.Contains(out, unwanted) {
+			t.Errorf("output contains unescaped %q:\n%s", unwanted, out)
+		}
+	}
+	for _, want := range []string{
+		"&lt;script&gt;alert(1)&lt;/script&gt;",
+		"&lt;b&gt;bold&lt;/b&gt;",
+		"&#34;quoted&#34;",
+		"&amp; more",
+		"<br>\nsecond line",
+		"<h1>&lt;team&gt; &amp; *friends*</h1>",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestJSONIsValid(t *testing.T) {
+	out := render(t, "json", newMessage(hostile), deletedMessage())
+
+	var doc struct {
+		Export   header   `json:"export"`
+		Messages []record `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+	if len(doc.Messages) != 2 {
+		t.Fatalf("got %d messages, want 2", len(doc.Messages))
+	}
+	if doc.Messages[0].Content != hostile {
+		t.Errorf("content = %q, want %q", doc.Messages[0].Content, hostile)
+	}
+	if !doc.Messages[1].Deleted || doc.Messages[1].Content != DeletedPlaceholder {
+		t.Errorf("deleted message = %+v, want a tombstone", doc.Messages[1])
+	}
+}
+
+func TestEmptyJSONExport(t *testing.T) {
+	out := render(t, "json")
+
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(out), &doc); err != nil {
+		t.Fatalf("output is not valid JSON: %v\n%s", err, out)
+	}
+}
+
+func TestDeletedContentNeverLeaks(t *testing.T) {
+	m := deletedMessage()
+	m.Content = "should not be exported"
+
+	for _, format := range []string{"json", "csv", "md", "html"} {
+		out := render(t, format, m)
+		if strings.Contains(out, "should not be exported") {
+			t.Errorf("%s export contains the deleted message's content", format)
+		}
+		if !strings.Contains(out, "message deleted") {
+			t.Errorf("%s export does not mark the message as deleted", format)
+		}
+	}
+}
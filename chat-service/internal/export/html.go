@@ -0,0 +1,75 @@
+package export
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+)
+
+type htmlWriter struct {
+	w io.Writer
+}
+
+func newHTMLWriter(w io.Writer) Writer {
+	return &htmlWriter{w: w}
+}
+
+func (h *htmlWriter) Begin(room *models.Room, query *models.ExportQuery) error {
+	hd := newHeader(room, query)
+	name := html.EscapeString(hd.RoomName)
+
+	var b strings.Builder
+	b.WriteString("<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n")
+	fmt.Fprintf(&b, "<title>%s</title>\n</head>\n<body>\n<h1>%s</h1>\n", name, name)
+	fmt.Fprintf(&b, "<p>Room <code>%s</code>, exported <time datetime=\"%s\">%s</time>", hd.RoomID, hd.ExportedAt, hd.ExportedAt)
+	if hd.From != "" {
+		fmt.Fprintf(&b, ", from <time datetime=\"%s\">%s</time>", hd.From, hd.From)
+	}
+	if hd.To != "" {
+		fmt.Fprintf(&b, ", to <time datetime=\"%s\">%s</time>", hd.To, hd.To)
+	}
+	b.WriteString(".</p>\n<ol class=\"messages\">\n")
+
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *htmlWriter) WriteMessage(message *models.Message) error {
+	rec := newRecord(message)
+
+	class := "message"
+	if rec.Deleted {
+		class += " deleted"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<li id=\"message-%s\" class=\"%s\" value=\"%d\">\n", rec.ID, class, rec.Seq)
+	fmt.Fprintf(&b, "<header>#%d · <strong>%s</strong> · <time datetime=\"%s\">%s</time></header>\n",
+		rec.Seq, html.EscapeString(rec.Author), rec.Timestamp, rec.Timestamp)
+
+	content := strings.ReplaceAll(html.EscapeString(rec.Content), "\n", "<br>\n")
+	if rec.Deleted {
+		content = "<em>" + content + "</em>"
+	}
+	fmt.Fprintf(&b, "<p>%s</p>\n", content)
+
+	if notes := rec.notes(); len(notes) > 0 {
+		fmt.Fprintf(&b, "<footer>%s</footer>\n", html.EscapeString(strings.Join(notes, "; ")))
+	}
+	b.WriteString("</li>\n")
+
+	_, err := io.WriteString(h.w, b.String())
+	return err
+}
+
+func (h *htmlWriter) Flush() error {
+	return nil
+}
+
+func (h *htmlWriter) End() error {
+	_, err := io.WriteString(h.w, "</ol>\n</body>\n</html>\n")
+	return err
+}
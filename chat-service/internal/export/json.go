@@ -0,0 +1,54 @@
+package export
+
+import (
+	"encoding/json"
+	"io"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+)
+
+// jsonWriter writes {"export": header, "messages": [record, ...]}, one
+// message per line.
+type jsonWriter struct {
+	w     io.Writer
+	count int
+}
+
+func newJSONWriter(w io.Writer) Writer {
+	return &jsonWriter{w: w}
+}
+
+func (j *jsonWriter) Begin(room *models.Room, query *models.ExportQuery) error {
+	data, err := json.Marshal(newHeader(room, query))
+	if err != nil {
+		return err
+	}
+
+	_, err = io.WriteString(j.w, `{"export":`+string(data)+`,"messages":[`)
+	return err
+}
+
+func (j *jsonWriter) WriteMessage(message *models.Message) error {
+	data, err := json.Marshal(newRecord(message))
+	if err != nil {
+		return err
+	}
+
+	separator := "\n"
+	if j.count > 0 {
+		separator = ",\n"
+	}
+	j.count++
+
+	_, err = io.WriteString(j.w, separator+string(data))
+	return err
+}
+
+func (j *jsonWriter) Flush() error {
+	return nil
+}
+
+func (j *jsonWriter) End() error {
+	_, err := io.WriteString(j.w, "\n]}\n")
+	return err
+}
@@ -0,0 +1,68 @@
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+)
+
+// markdownEscaper keeps user text from being read as markup; line breaks
+// become hard breaks so multi-line messages keep their shape.
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`,
+	"<", `\<`, ">", `\>`, "#", `\#`, "|", `\|`, "\n", "  \n",
+)
+
+type markdownWriter struct {
+	w io.Writer
+}
+
+func newMarkdownWriter(w io.Writer) Writer {
+	return &markdownWriter{w: w}
+}
+
+func (m *markdownWriter) Begin(room *models.Room, query *models.ExportQuery) error {
+	h := newHeader(room, query)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", markdownEscaper.Replace(h.RoomName))
+	fmt.Fprintf(&b, "Room `%s`, exported %s", h.RoomID, h.ExportedAt)
+	if h.From != "" {
+		fmt.Fprintf(&b, ", from %s", h.From)
+	}
+	if h.To != "" {
+		fmt.Fprintf(&b, ", to %s", h.To)
+	}
+	b.WriteString(".\n")
+
+	_, err := io.WriteString(m.w, b.String())
+	return err
+}
+
+func (m *markdownWriter) WriteMessage(message *models.Message) error {
+	rec := newRecord(message)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "\n### #%d · %s · %s\n\n", rec.Seq, markdownEscaper.Replace(rec.Author), rec.Timestamp)
+	if rec.Deleted {
+		fmt.Fprintf(&b, "_%s_\n", markdownEscaper.Replace(rec.Content))
+	} else {
+		fmt.Fprintf(&b, "%s\n", markdownEscaper.Replace(rec.Content))
+	}
+	if notes := rec.notes(); len(notes) > 0 {
+		fmt.Fprintf(&b, "\n_(%s)_\n", strings.Join(notes, "; "))
+	}
+
+	_, err := io.WriteString(m.w, b.String())
+	return err
+}
+
+func (m *markdownWriter) Flush() error {
+	return nil
+}
+
+func (m *markdownWriter) End() error {
+	return nil
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/export"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/gorilla/mux"
+)
+
+// exportPageTimeout is how long writing one page of an export may take. The
+// deadline is pushed back after every page so large rooms are not cut off by
+// the server's write timeout.
+const exportPageTimeout = 30 * time.Second
+
+// ExportRoom streams the room's transcript as json, csv, md or html. from
+// and to take RFC 3339 timestamps or plain dates.
+func (h *WebSocketHandler) ExportRoom(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	params := r.URL.Query()
+
+	name := params.Get("format")
+	if name == "" {
+		name = "json"
+	}
+	format, ok := export.Lookup(name)
+	if !ok {
+		h.respondError(w, http.StatusBadRequest, "Invalid format")
+		return
+	}
+
+	query := &models.ExportQuery{}
+	if query.From, err = parseSearchTime(params.Get("from")); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid from")
+		return
+	}
+
+	if query.To, err = parseSearchTime(params.Get("to")); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid to")
+		return
+	}
+
+	roomID := mux.Vars(r)["roomId"]
+
+	room, next, err := h.chatService.ExportRoom(roomID, claims.UserID, query)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to export room")
+		return
+	}
+
+	w.Header().Set("Content-Type", format.ContentType)
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="room-%s.%s"`, room.ID, format.Extension))
+	w.WriteHeader(http.StatusOK)
+
+	// The status is already sent, so from here on a failure can only cut the
+	// transcript short.
+	rc := http.NewResponseController(w)
+	writer := format.NewWriter(w)
+
+	if err := writer.Begin(room, query); err != nil {
+		h.logger.Error("Failed to write export", "roomID", roomID, "error", err)
+		return
+	}
+
+	for {
+		rc.SetWriteDeadline(time.Now().Add(exportPageTimeout))
+
+		messages, err := next()
+		if err != nil {
+			h.logger.Error("Failed to fetch messages for export", "roomID", roomID, "error", err)
+			return
+		}
+		if len(messages) == 0 {
+			break
+		}
+
+		for _, message := range messages {
+			if err := writer.WriteMessage(message); err != nil {
+				h.logger.Error("Failed to write export", "roomID", roomID, "error", err)
+				return
+			}
+		}
+
+		if err := writer.Flush(); err != nil {
+			h.logger.Error("Failed to write export", "roomID", roomID, "error", err)
+			return
+		}
+		rc.Flush()
+	}
+
+	if err := writer.End(); err != nil {
+		h.logger.Error("Failed to write export", "roomID", roomID, "error", err)
+	}
+}
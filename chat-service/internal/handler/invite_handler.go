@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func (h *WebSocketHandler) InviteUser(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.CreateInvitationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	invitation, err := h.chatService.InviteUser(mux.Vars(r)["roomId"], claims.UserID, req.UserID)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to invite user")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, invitation)
+}
+
+func (h *WebSocketHandler) CreateInviteLink(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.CreateInviteLinkRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	link, err := h.chatService.CreateInviteLink(mux.Vars(r)["roomId"], claims.UserID, &req)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to create invite link")
+		return
+	}
+
+	h.respondJSON(w, http.StatusCreated, link)
+}
+
+// AcceptInvite redeems an invite token and returns the room it opens.
+func (h *WebSocketHandler) AcceptInvite(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	room, err := h.chatService.AcceptInviteLink(mux.Vars(r)["token"], claims.UserID, claims.Username)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to accept invite")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, room)
+}
@@ -0,0 +1,50 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// GetUnreadCounts returns the caller's unread count for each of their rooms.
+func (h *WebSocketHandler) GetUnreadCounts(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	counts, err := h.chatService.GetUnreadCounts(claims.UserID)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to fetch unread counts")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, counts)
+}
+
+// GetMentions pages through messages that mention the caller, newest first.
+func (h *WebSocketHandler) GetMentions(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	params := r.URL.Query()
+
+	limit := 0
+	if raw := params.Get("limit"); raw != "" {
+		if limit, err = strconv.Atoi(raw); err != nil {
+			h.respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	page, err := h.chatService.GetMentions(claims.UserID, params.Get("before"), limit)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to fetch mentions")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, page)
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func (h *WebSocketHandler) PromoteMember(w http.ResponseWriter, r *http.Request) {
+	h.setMemberRole(w, r, models.RoleAdmin)
+}
+
+func (h *WebSocketHandler) DemoteMember(w http.ResponseWriter, r *http.Request) {
+	h.setMemberRole(w, r, models.RoleMember)
+}
+
+func (h *WebSocketHandler) setMemberRole(w http.ResponseWriter, r *http.Request, role string) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	participant, changed, err := h.chatService.SetParticipantRole(vars["roomId"], claims.UserID, vars["userId"], role)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to change role")
+		return
+	}
+
+	if changed {
+		h.hub.BroadcastEvent(models.NewRoleChangedEvent(participant))
+	}
+
+	h.respondJSON(w, http.StatusOK, participant)
+}
+
+// ModerateMember kicks, bans or mutes the user named in the path, depending
+// on the action segment.
+func (h *WebSocketHandler) ModerateMember(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.ModerationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	entry, err := h.chatService.Moderate(vars["roomId"], claims.UserID, vars["userId"], vars["action"], &req)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to moderate user")
+		return
+	}
+
+	h.hub.BroadcastEvent(models.NewModerationEvent(entry))
+
+	h.respondJSON(w, http.StatusOK, entry)
+}
@@ -0,0 +1,81 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func (h *WebSocketHandler) GetThread(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	query, err := parseMessageQuery(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
+
+	thread, err := h.chatService.GetThread(vars["roomId"], vars["messageId"], claims.UserID, query)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to fetch thread")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, thread)
+}
+
+func (h *WebSocketHandler) EditMessage(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	var req models.EditMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	message, err := h.chatService.EditMessage(vars["roomId"], vars["id"], claims.UserID, req.Content)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to edit message")
+		return
+	}
+
+	h.hub.BroadcastEvent(models.NewEditedEvent(message))
+
+	h.respondJSON(w, http.StatusOK, message)
+}
+
+func (h *WebSocketHandler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	message, deleted, err := h.chatService.DeleteMessage(vars["roomId"], vars["id"], claims.UserID)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to delete message")
+		return
+	}
+
+	if deleted {
+		h.hub.BroadcastEvent(models.NewDeletedEvent(message))
+	}
+
+	h.respondJSON(w, http.StatusOK, message)
+}
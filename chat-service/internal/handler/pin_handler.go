@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func (h *WebSocketHandler) GetPins(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	pins, err := h.chatService.GetPins(mux.Vars(r)["roomId"], claims.UserID)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to fetch pins")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, pins)
+}
+
+func (h *WebSocketHandler) PinMessage(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	message, pin, added, err := h.chatService.PinMessage(vars["roomId"], vars["messageId"], claims.UserID)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to pin message")
+		return
+	}
+
+	if added {
+		h.hub.BroadcastEvent(models.NewPinAddedEvent(message, pin))
+	}
+
+	h.respondJSON(w, http.StatusOK, pin)
+}
+
+func (h *WebSocketHandler) UnpinMessage(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	vars := mux.Vars(r)
+
+	removed, err := h.chatService.UnpinMessage(vars["roomId"], vars["messageId"], claims.UserID)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to unpin message")
+		return
+	}
+
+	if removed {
+		h.hub.BroadcastEvent(models.NewPinRemovedEvent(vars["roomId"], vars["messageId"], claims.UserID))
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/gorilla/mux"
+)
+
+func (h *WebSocketHandler) UpdateRoom(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.UpdateRoomRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	room, err := h.chatService.UpdateRoom(mux.Vars(r)["roomId"], claims.UserID, &req)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to update room")
+		return
+	}
+
+	h.hub.BroadcastEvent(models.NewRoomUpdatedEvent(room))
+
+	h.respondJSON(w, http.StatusOK, room)
+}
+
+func (h *WebSocketHandler) ArchiveRoom(w http.ResponseWriter, r *http.Request) {
+	h.setRoomArchived(w, r, true)
+}
+
+func (h *WebSocketHandler) UnarchiveRoom(w http.ResponseWriter, r *http.Request) {
+	h.setRoomArchived(w, r, false)
+}
+
+func (h *WebSocketHandler) setRoomArchived(w http.ResponseWriter, r *http.Request, archived bool) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	room, err := h.chatService.SetRoomArchived(mux.Vars(r)["roomId"], claims.UserID, archived)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to archive room")
+		return
+	}
+
+	h.hub.BroadcastEvent(models.NewRoomUpdatedEvent(room))
+
+	h.respondJSON(w, http.StatusOK, room)
+}
+
+// DeleteRoom removes the room for good and drops its members from it on
+// every node.
+func (h *WebSocketHandler) DeleteRoom(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	roomID := mux.Vars(r)["roomId"]
+
+	if err := h.chatService.DeleteRoom(roomID, claims.UserID); err != nil {
+		h.respondServiceError(w, err, "Failed to delete room")
+		return
+	}
+
+	h.hub.BroadcastEvent(models.NewRoomDeletedEvent(roomID))
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebSocketHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.TransferOwnershipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	previous, owner, err := h.chatService.TransferOwnership(mux.Vars(r)["roomId"], claims.UserID, req.UserID)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to transfer ownership")
+		return
+	}
+
+	h.hub.BroadcastEvent(models.NewRoleChangedEvent(previous))
+	h.hub.BroadcastEvent(models.NewRoleChangedEvent(owner))
+
+	h.respondJSON(w, http.StatusOK, owner)
+}
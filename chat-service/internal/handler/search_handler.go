@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+)
+
+// SearchMessages runs a full-text search over the caller's rooms. before and
+// after take RFC 3339 timestamps or plain dates.
+func (h *WebSocketHandler) SearchMessages(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	params := r.URL.Query()
+	query := &models.SearchQuery{
+		Text:   params.Get("q"),
+		RoomID: params.Get("room_id"),
+		From:   params.Get("from"),
+	}
+
+	if query.Before, err = parseSearchTime(params.Get("before")); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid before")
+		return
+	}
+
+	if query.After, err = parseSearchTime(params.Get("after")); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid after")
+		return
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		if query.Limit, err = strconv.Atoi(limit); err != nil {
+			h.respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+	}
+
+	if offset := params.Get("offset"); offset != "" {
+		if query.Offset, err = strconv.Atoi(offset); err != nil {
+			h.respondError(w, http.StatusBadRequest, "Invalid offset")
+			return
+		}
+	}
+
+	page, err := h.chatService.SearchMessages(claims.UserID, query)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to search messages")
+		return
+	}
+
+	h.respondJSON(w, http.StatusOK, page)
+}
+
+// parseSearchTime returns nil for an empty parameter.
+func parseSearchTime(raw string) (*time.Time, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		if t, err = time.Parse(time.DateOnly, raw); err != nil {
+			return nil, err
+		}
+	}
+	return &t, nil
+}
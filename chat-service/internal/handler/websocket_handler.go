@@ -2,7 +2,9 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"strings"
 
 	"github.com/dmehra2102/go-realtime-chat/auth-service/pkg/jwt"
@@ -10,6 +12,7 @@ import (
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/hub"
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/service"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/presence"
 	"github.com/dmehra2102/go-realtime-chat/shared/pkg/logger"
 	"github.com/gorilla/mux"
 	"github.com/gorilla/websocket"
@@ -31,14 +34,16 @@ var upgrader = websocket.Upgrader{
 type WebSocketHandler struct {
 	hub         *hub.Hub
 	chatService service.ChatService
+	presence    presence.Tracker
 	jwtSecret   string
 	logger      *logger.Logger
 }
 
-func NewWebSocketHandler(hub *hub.Hub, chatService service.ChatService, jwtSecret string, logger *logger.Logger) *WebSocketHandler {
+func NewWebSocketHandler(hub *hub.Hub, chatService service.ChatService, tracker presence.Tracker, jwtSecret string, logger *logger.Logger) *WebSocketHandler {
 	return &WebSocketHandler{
 		hub:         hub,
 		chatService: chatService,
+		presence:    tracker,
 		jwtSecret:   jwtSecret,
 		logger:      logger,
 	}
@@ -78,8 +83,7 @@ func (h *WebSocketHandler) HandleWebSocket(w http.ResponseWriter, r *http.Reques
 }
 
 func (h *WebSocketHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
-	token := extractTokenFromHeader(r.Header.Get("Authorization"))
-	claims, err := jwt.ValidateToken(token, h.jwtSecret)
+	claims, err := h.authenticate(r)
 	if err != nil {
 		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
 		return
@@ -91,36 +95,143 @@ func (h *WebSocketHandler) CreateRoom(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	room, err := h.chatService.CreateRoom(&req, claims.UserID)
+	room, err := h.chatService.CreateRoom(&req, claims.UserID, claims.Username)
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, "Failed to create room")
+		h.respondServiceError(w, err, "Failed to create room")
 		return
 	}
 
 	h.respondJSON(w, http.StatusCreated, room)
 }
 
+// ListRooms lists public rooms to anyone; mine=true lists the caller's own
+// rooms and needs a token.
 func (h *WebSocketHandler) ListRooms(w http.ResponseWriter, r *http.Request) {
-	rooms, err := h.chatService.ListRooms()
+	params := r.URL.Query()
+	query := &models.RoomQuery{
+		Search: strings.TrimSpace(params.Get("q")),
+		Mine:   params.Get("mine") == "true",
+		Cursor: params.Get("cursor"),
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			h.respondError(w, http.StatusBadRequest, "Invalid limit")
+			return
+		}
+		query.Limit = n
+	}
+
+	userID := ""
+	if query.Mine {
+		claims, err := h.authenticate(r)
+		if err != nil {
+			h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+		userID = claims.UserID
+	}
+
+	page, err := h.chatService.ListRooms(userID, query)
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, "Failed to fetch rooms")
+		h.respondServiceError(w, err, "Failed to fetch rooms")
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, rooms)
+	h.respondJSON(w, http.StatusOK, page)
+}
+
+// OpenDirectMessage returns the caller's direct room with another user,
+// creating it if this is their first conversation.
+func (h *WebSocketHandler) OpenDirectMessage(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	var req models.CreateDirectMessageRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid request body")
+		return
+	}
+
+	room, created, err := h.chatService.OpenDirectMessage(claims.UserID, claims.Username, req.UserID)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to open direct message")
+		return
+	}
+
+	status := http.StatusOK
+	if created {
+		status = http.StatusCreated
+	}
+	h.respondJSON(w, status, room)
 }
 
 func (h *WebSocketHandler) GetRoomMessages(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
 	vars := mux.Vars(r)
-	roomID := vars["roomID"]
+	roomID := vars["roomId"]
+
+	query, err := parseMessageQuery(r)
+	if err != nil {
+		h.respondError(w, http.StatusBadRequest, "Invalid limit")
+		return
+	}
 
-	messages, err := h.chatService.GetRoomMessages(roomID, 50)
+	page, err := h.chatService.GetRoomMessages(roomID, claims.UserID, query)
 	if err != nil {
-		h.respondError(w, http.StatusInternalServerError, "Failed to fetch messages")
+		h.respondServiceError(w, err, "Failed to fetch messages")
 		return
 	}
 
-	h.respondJSON(w, http.StatusOK, messages)
+	h.respondJSON(w, http.StatusOK, page)
+}
+
+// GetRoomPresence lists the room's participants who are online or away on
+// any node.
+func (h *WebSocketHandler) GetRoomPresence(w http.ResponseWriter, r *http.Request) {
+	claims, err := h.authenticate(r)
+	if err != nil {
+		h.respondError(w, http.StatusUnauthorized, "Unauthorized")
+		return
+	}
+
+	roomID := mux.Vars(r)["roomId"]
+
+	userIDs, err := h.chatService.GetRoomParticipantIDs(roomID, claims.UserID)
+	if err != nil {
+		h.respondServiceError(w, err, "Failed to fetch presence")
+		return
+	}
+
+	statuses, err := h.presence.Statuses(r.Context(), userIDs)
+	if err != nil {
+		h.logger.Error("Failed to fetch presence", "roomID", roomID, "error", err)
+		h.respondError(w, http.StatusInternalServerError, "Failed to fetch presence")
+		return
+	}
+
+	online := make([]models.UserPresence, 0, len(statuses))
+	for _, userID := range userIDs {
+		if status := statuses[userID]; status != presence.StatusOffline {
+			online = append(online, models.UserPresence{UserID: userID, Status: status})
+		}
+	}
+
+	h.respondJSON(w, http.StatusOK, online)
+}
+
+func (h *WebSocketHandler) authenticate(r *http.Request) (*jwt.Claims, error) {
+	token := extractTokenFromHeader(r.Header.Get("Authorization"))
+	return jwt.ValidateToken(token, h.jwtSecret)
 }
 
 func (h *WebSocketHandler) respondJSON(w http.ResponseWriter, status int, data any) {
@@ -133,6 +244,56 @@ func (h *WebSocketHandler) respondError(w http.ResponseWriter, status int, messa
 	h.respondJSON(w, status, map[string]string{"error": message})
 }
 
+func parseMessageQuery(r *http.Request) (*models.MessageQuery, error) {
+	params := r.URL.Query()
+	query := &models.MessageQuery{
+		Before: params.Get("before"),
+		After:  params.Get("after"),
+		Around: params.Get("around"),
+	}
+
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil {
+			return nil, err
+		}
+		query.Limit = n
+	}
+
+	return query, nil
+}
+
+// respondServiceError maps the service's public errors to a status code and
+// hides everything else behind fallback.
+func (h *WebSocketHandler) respondServiceError(w http.ResponseWriter, err error, fallback string) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrRoomNotFound), errors.Is(err, service.ErrMessageNotFound),
+		errors.Is(err, service.ErrInvalidInvite), errors.Is(err, service.ErrParticipantNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, service.ErrInvalidRoomID), errors.Is(err, service.ErrInvalidUserID),
+		errors.Is(err, service.ErrInvalidCursor), errors.Is(err, service.ErrEmptyMessage),
+		errors.Is(err, service.ErrInvalidEmoji), errors.Is(err, service.ErrInvalidParent),
+		errors.Is(err, service.ErrDirectMessageSelf), errors.Is(err, service.ErrInvalidVisibility),
+		errors.Is(err, service.ErrInvalidInviteLink), errors.Is(err, service.ErrInvalidRole),
+		errors.Is(err, service.ErrInvalidModeration), errors.Is(err, service.ErrInvalidMuteDuration),
+		errors.Is(err, service.ErrInvalidRoomName), errors.Is(err, service.ErrInvalidTransfer),
+		errors.Is(err, service.ErrInvalidSearch), errors.Is(err, service.ErrInvalidExportRange):
+		status = http.StatusBadRequest
+	case errors.Is(err, service.ErrMessageDeleted), errors.Is(err, service.ErrRoomArchived),
+		errors.Is(err, service.ErrTooManyPins):
+		status = http.StatusConflict
+	case errors.Is(err, service.ErrForbidden), errors.Is(err, service.ErrNotInRoom),
+		errors.Is(err, service.ErrBanned), errors.Is(err, service.ErrMuted):
+		status = http.StatusForbidden
+	}
+
+	if status == http.StatusInternalServerError {
+		h.logger.Error(fallback, "error", err)
+	}
+	h.respondError(w, status, service.ErrorMessage(err, fallback))
+}
+
 func extractTokenFromHeader(authHeader string) string {
 	parts := strings.Split(authHeader, " ")
 	if len(parts) == 2 && parts[0] == "Bearer" {
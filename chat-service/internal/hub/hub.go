@@ -9,44 +9,77 @@ import (
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/client"
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/service"
-	redispkg "github.com/dmehra2102/go-realtime-chat/chat-service/pkg/redis"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/broker"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/presence"
 	"github.com/dmehra2102/go-realtime-chat/shared/pkg/logger"
+	"github.com/google/uuid"
 )
 
+const (
+	roomChannelPrefix = "chat.room."
+	userChannelPrefix = "chat.user."
+)
+
+// inbound is a frame read from a local connection, kept together with its
+// sender so the hub can answer that connection directly.
+type inbound struct {
+	client  *client.Client
+	message *models.WebSocketMessage
+}
+
 type Hub struct {
-	clients     map[*client.Client]bool
-	rooms       map[string]map[*client.Client]bool
-	register    chan *client.Client
-	unregister  chan *client.Client
-	broadcast   chan *models.WebSocketMessage
-	redisPubSub *redispkg.RedisPubSub
-	chatService service.ChatService
-	logger      *logger.Logger
-	mu          sync.RWMutex
-	ctx         context.Context
-	cancel      context.CancelFunc
-}
-
-func NewHub(redisPubSub *redispkg.RedisPubSub, chatService service.ChatService, logger *logger.Logger) *Hub {
+	nodeID        string
+	clients       map[uuid.UUID]*client.Client
+	users         map[string]map[uuid.UUID]*client.Client
+	rooms         map[string]map[uuid.UUID]*client.Client
+	subscription  broker.Subscription
+	register      chan *client.Client
+	unregister    chan *client.Client
+	broadcast     chan *inbound
+	remote        chan *models.Envelope
+	events        chan *models.WebSocketMessage
+	typing        map[typingKey]*typingState
+	typingExpired chan typingExpiry
+	replays       map[replayKey]*replay
+	replayed      chan *replayResult
+	broker        broker.Broker
+	presence      presence.Tracker
+	chatService   service.ChatService
+	logger        *logger.Logger
+	mu            sync.RWMutex
+	ctx           context.Context
+	cancel        context.CancelFunc
+}
+
+func NewHub(broker broker.Broker, tracker presence.Tracker, chatService service.ChatService, nodeID string, logger *logger.Logger) *Hub {
 	ctx, cancel := context.WithCancel(context.Background())
-	h := &Hub{
-		clients:     make(map[*client.Client]bool),
-		rooms:       make(map[string]map[*client.Client]bool),
-		register:    make(chan *client.Client),
-		unregister:  make(chan *client.Client),
-		broadcast:   make(chan *models.WebSocketMessage),
-		redisPubSub: redisPubSub,
-		chatService: chatService,
-		logger:      logger,
-		ctx:         ctx,
-		cancel:      cancel,
-	}
-	// Subscribe to Redis messages
-	go h.subscribeToRedis()
-	return h
+	return &Hub{
+		nodeID:        nodeID,
+		clients:       make(map[uuid.UUID]*client.Client),
+		users:         make(map[string]map[uuid.UUID]*client.Client),
+		rooms:         make(map[string]map[uuid.UUID]*client.Client),
+		subscription:  broker.Subscribe(ctx),
+		register:      make(chan *client.Client),
+		unregister:    make(chan *client.Client),
+		broadcast:     make(chan *inbound),
+		remote:        make(chan *models.Envelope, 256),
+		events:        make(chan *models.WebSocketMessage, 64),
+		typing:        make(map[typingKey]*typingState),
+		typingExpired: make(chan typingExpiry, 64),
+		replays:       make(map[replayKey]*replay),
+		replayed:      make(chan *replayResult, 64),
+		broker:        broker,
+		presence:      tracker,
+		chatService:   chatService,
+		logger:        logger,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
 }
 
 func (h *Hub) Run() {
+	go h.consume()
+
 	for {
 		select {
 		case <-h.ctx.Done():
@@ -56,8 +89,16 @@ func (h *Hub) Run() {
 			h.handleRegister(client)
 		case client := <-h.unregister:
 			h.handleUnregister(client)
-		case message := <-h.broadcast:
-			h.handleBroadcast(message)
+		case in := <-h.broadcast:
+			h.handleBroadcast(in.client, in.message)
+		case envelope := <-h.remote:
+			h.handleRemote(envelope)
+		case event := <-h.events:
+			h.deliver(event)
+		case expiry := <-h.typingExpired:
+			h.handleTypingExpired(expiry)
+		case result := <-h.replayed:
+			h.finishResume(result)
 		}
 	}
 }
@@ -70,188 +111,527 @@ func (h *Hub) Unregister(client *client.Client) {
 	h.unregister <- client
 }
 
-func (h *Hub) Broadcast(message *models.WebSocketMessage) {
-	h.broadcast <- message
+func (h *Hub) Broadcast(sender *client.Client, message *models.WebSocketMessage) {
+	h.broadcast <- &inbound{client: sender, message: message}
+}
+
+// BroadcastEvent delivers an event produced outside a websocket connection,
+// such as a REST call, to the room's members on every node.
+func (h *Hub) BroadcastEvent(event *models.WebSocketMessage) {
+	h.events <- event
 }
 
-func (h *Hub) handleRegister(client *client.Client) {
+func (h *Hub) handleRegister(c *client.Client) {
 	h.mu.Lock()
-	h.clients[client] = true
+	h.clients[c.ID] = c
+	if h.users[c.UserID] == nil {
+		h.users[c.UserID] = make(map[uuid.UUID]*client.Client)
+		h.subscription.Add(userChannel(c.UserID))
+	}
+	h.users[c.UserID][c.ID] = c
 	h.mu.Unlock()
 
-	h.logger.Info("Client registered", "userID", client.UserID, "username", client.Username)
+	h.trackConnect(c)
+
+	h.logger.Info("Client registered", "userID", c.UserID, "username", c.Username)
 }
 
-func (h *Hub) handleUnregister(client *client.Client) {
+func (h *Hub) handleUnregister(c *client.Client) {
 	h.mu.Lock()
-	if _, ok := h.clients[client]; ok {
-		delete(h.clients, client)
-
-		for roomID := range client.Rooms {
-			if room, exists := h.rooms[roomID]; exists {
-				delete(room, client)
-				if len(room) == 0 {
-					delete(h.rooms, roomID)
-				}
-
-				// Broadcast leave message
-				leaveMsg := &models.WebSocketMessage{
-					Type:     "leave",
-					RoomID:   roomID,
-					UserID:   client.UserID,
-					Username: client.Username,
-				}
-				h.broadcastToRoom(roomID, leaveMsg, client)
-			}
-		}
+	if _, ok := h.clients[c.ID]; !ok {
+		h.mu.Unlock()
+		return
+	}
+	delete(h.clients, c.ID)
+	delete(h.users[c.UserID], c.ID)
+	if len(h.users[c.UserID]) == 0 {
+		delete(h.users, c.UserID)
+		h.subscription.Remove(userChannel(c.UserID))
+	}
 
-		client.Close()
+	// Only announce a leave for rooms the user has no other tab in.
+	var left []string
+	for roomID := range c.Rooms {
+		if h.removeFromRoom(roomID, c) && !h.userInRoom(c.UserID, roomID) {
+			left = append(left, roomID)
+		}
 	}
+	c.Close()
 	h.mu.Unlock()
 
-	h.logger.Info("Client unregistered", "userID", client.UserID)
+	for _, roomID := range left {
+		leaveMsg := &models.WebSocketMessage{
+			Type:     "leave",
+			RoomID:   roomID,
+			UserID:   c.UserID,
+			Username: c.Username,
+		}
+		h.broadcastToRoom(roomID, leaveMsg, c)
+		h.publish(leaveMsg)
+
+		h.stopTyping(typingKey{roomID: roomID, userID: c.UserID}, c.Username)
+	}
+
+	h.trackDisconnect(c)
+
+	h.logger.Info("Client unregistered", "userID", c.UserID)
 }
 
-func (h *Hub) handleBroadcast(message *models.WebSocketMessage) {
+func (h *Hub) handleBroadcast(sender *client.Client, message *models.WebSocketMessage) {
 	switch message.Type {
 	case "join":
-		h.handleJoinRoom(message)
+		h.handleJoinRoom(sender, message)
 	case "leave":
-		h.handleLeaveRoom(message)
+		h.handleLeaveRoom(sender, message)
 	case "message":
-		h.handleMessage(message)
+		h.handleMessage(sender, message)
+	case "resume":
+		h.handleResume(sender, message)
+	case "typing_start":
+		h.handleTypingStart(sender, message)
+	case "typing_stop":
+		h.handleTypingStop(sender, message)
+	case "presence":
+		h.handlePresence(sender, message)
+	case "message_edit":
+		h.handleMessageEdit(sender, message)
+	case "message_delete":
+		h.handleMessageDelete(sender, message)
+	case "reaction_add", "reaction_remove":
+		h.handleReaction(sender, message)
+	case "read":
+		h.handleRead(sender, message)
+	case models.ModerationKick, models.ModerationBan, models.ModerationMute:
+		h.handleModeration(sender, message)
 	}
 }
 
-func (h *Hub) handleJoinRoom(message *models.WebSocketMessage) {
-	h.mu.Lock()
-
-	var targetClient *client.Client
-	for c := range h.clients {
-		if c.UserID == message.UserID {
-			targetClient = c
-			break
-		}
+// handleJoinRoom joins the sending connection, or all of the user's
+// connections on this node when AllDevices is set. The user's other devices
+// already in the room see the join frame too, which is how they stay in sync.
+func (h *Hub) handleJoinRoom(sender *client.Client, message *models.WebSocketMessage) {
+	if err := h.chatService.JoinRoom(message.RoomID, sender.UserID, sender.Username); err != nil {
+		h.logger.Warn("Join rejected", "userID", sender.UserID, "roomID", message.RoomID, "error", err)
+		h.sendError(sender, message, service.ErrorMessage(err, "failed to join room"))
+		return
 	}
 
-	if targetClient != nil {
-		if h.rooms[message.RoomID] == nil {
-			h.rooms[message.RoomID] = make(map[*client.Client]bool)
-		}
-		h.rooms[message.RoomID][targetClient] = true
-		targetClient.Rooms[message.RoomID] = true
+	h.mu.Lock()
+	for _, c := range h.targets(sender, message.AllDevices) {
+		h.addToRoom(message.RoomID, c)
 	}
-
 	h.mu.Unlock()
 
-	h.broadcastToRoom(message.RoomID, message, targetClient)
+	h.broadcastToRoom(message.RoomID, message, sender)
 
-	h.publishToRedis(message)
+	h.publish(message)
 	h.logger.Info("User joined room", "userID", message.UserID, "roomID", message.RoomID)
 }
 
-func (h *Hub) handleLeaveRoom(message *models.WebSocketMessage) {
+func (h *Hub) handleLeaveRoom(sender *client.Client, message *models.WebSocketMessage) {
+	left := false
 	h.mu.Lock()
-
-	var targetClient *client.Client
-	for c := range h.clients {
-		if c.UserID == message.UserID {
-			targetClient = c
-			break
+	for _, c := range h.targets(sender, message.AllDevices) {
+		if h.removeFromRoom(message.RoomID, c) {
+			left = true
 		}
 	}
+	stillInRoom := h.userInRoom(sender.UserID, message.RoomID)
+	h.mu.Unlock()
 
-	if targetClient != nil {
-		if room, exists := h.rooms[message.RoomID]; exists {
-			delete(room, targetClient)
-			if len(room) == 0 {
-				delete(h.rooms, message.RoomID)
-			}
-		}
-		delete(targetClient.Rooms, message.RoomID)
+	// Nothing to announce for a room none of the connections was in, or
+	// while the user is still present through another tab.
+	if !left || stillInRoom {
+		return
 	}
 
-	h.mu.Unlock()
+	h.broadcastToRoom(message.RoomID, message, sender)
 
-	h.broadcastToRoom(message.RoomID, message, targetClient)
+	h.publish(message)
 
-	h.publishToRedis(message)
+	h.stopTyping(typingKey{roomID: message.RoomID, userID: message.UserID}, message.Username)
 
 	h.logger.Info("User left room", "userID", message.UserID, "roomID", message.RoomID)
 }
 
-func (h *Hub) handleMessage(message *models.WebSocketMessage) {
+func (h *Hub) handleMessage(sender *client.Client, message *models.WebSocketMessage) {
+	if !sender.Rooms[message.RoomID] {
+		h.sendError(sender, message, service.ErrNotInRoom.Error())
+		return
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	if err := h.chatService.SaveMessage(ctx, message); err != nil {
+	saved, created, err := h.chatService.SaveMessage(ctx, message)
+	if err != nil {
 		h.logger.Error("Failed to save message", "error", err)
+		h.sendError(sender, message, service.ErrorMessage(err, "failed to save message"))
+		return
+	}
+
+	// The room gets the frame built from what was stored, the same one
+	// history and resume serve, never the one the client sent.
+	event := models.NewMessageEvent(saved)
+
+	sender.SendMessage(&models.WebSocketMessage{
+		Type:        "ack",
+		ID:          event.ID,
+		ClientMsgID: message.ClientMsgID,
+		RoomID:      event.RoomID,
+		Seq:         event.Seq,
+		Timestamp:   event.Timestamp,
+	})
+
+	// A retried client_msg_id was already delivered the first time round.
+	if !created {
+		return
 	}
 
-	h.broadcastToRoom(message.RoomID, message, nil)
+	h.broadcastToRoom(event.RoomID, event, nil)
+
+	h.publish(event)
 
-	h.publishToRedis(message)
+	if len(saved.Mentions) > 0 {
+		go h.deliverMentions(saved)
+	}
 }
 
-func (h *Hub) broadcastToRoom(roomID string, message *models.WebSocketMessage, except *client.Client) {
-	h.mu.Lock()
+func (h *Hub) handleMessageEdit(sender *client.Client, message *models.WebSocketMessage) {
+	if !sender.Rooms[message.RoomID] {
+		h.sendError(sender, message, service.ErrNotInRoom.Error())
+		return
+	}
+
+	edited, err := h.chatService.EditMessage(message.RoomID, message.ID, sender.UserID, message.Content)
+	if err != nil {
+		h.logger.Warn("Failed to edit message", "messageID", message.ID, "error", err)
+		h.sendError(sender, message, service.ErrorMessage(err, "failed to edit message"))
+		return
+	}
+
+	h.deliver(models.NewEditedEvent(edited))
+}
+
+func (h *Hub) handleMessageDelete(sender *client.Client, message *models.WebSocketMessage) {
+	if !sender.Rooms[message.RoomID] {
+		h.sendError(sender, message, service.ErrNotInRoom.Error())
+		return
+	}
+
+	tombstone, deleted, err := h.chatService.DeleteMessage(message.RoomID, message.ID, sender.UserID)
+	if err != nil {
+		h.logger.Warn("Failed to delete message", "messageID", message.ID, "error", err)
+		h.sendError(sender, message, service.ErrorMessage(err, "failed to delete message"))
+		return
+	}
+
+	// Deleting a tombstone again changes nothing the room has not seen, so
+	// only the sender hears back.
+	if !deleted {
+		sender.SendMessage(models.NewDeletedEvent(tombstone))
+		return
+	}
+
+	h.deliver(models.NewDeletedEvent(tombstone))
+}
+
+func (h *Hub) handleReaction(sender *client.Client, message *models.WebSocketMessage) {
+	if !sender.Rooms[message.RoomID] {
+		h.sendError(sender, message, service.ErrNotInRoom.Error())
+		return
+	}
+
+	var (
+		changed bool
+		err     error
+	)
+	if message.Type == "reaction_add" {
+		changed, err = h.chatService.AddReaction(message.RoomID, message.ID, sender.UserID, message.Emoji)
+	} else {
+		changed, err = h.chatService.RemoveReaction(message.RoomID, message.ID, sender.UserID, message.Emoji)
+	}
+	if err != nil {
+		h.logger.Warn("Failed to update reaction", "messageID", message.ID, "error", err)
+		h.sendError(sender, message, service.ErrorMessage(err, "failed to update reaction"))
+		return
+	}
+
+	if !changed {
+		return
+	}
+
+	h.deliver(&models.WebSocketMessage{
+		Type:     message.Type,
+		ID:       message.ID,
+		RoomID:   message.RoomID,
+		UserID:   sender.UserID,
+		Username: sender.Username,
+		Emoji:    message.Emoji,
+	})
+}
+
+// handleRead records how far the user has read. The receipt goes to the
+// whole room, including the user's other devices in it, so they can clear
+// their unread badges too.
+func (h *Hub) handleRead(sender *client.Client, message *models.WebSocketMessage) {
+	participant, changed, err := h.chatService.MarkRead(message.RoomID, sender.UserID, message.ID)
+	if err != nil {
+		h.logger.Warn("Failed to mark read", "roomID", message.RoomID, "messageID", message.ID, "error", err)
+		h.sendError(sender, message, service.ErrorMessage(err, "failed to mark read"))
+		return
+	}
+
+	if !changed {
+		return
+	}
+
+	h.deliver(models.NewReadReceiptEvent(participant, sender.Username))
+}
+
+func (h *Hub) sendError(c *client.Client, message *models.WebSocketMessage, reason string) {
+	c.SendMessage(&models.WebSocketMessage{
+		Type:        "error",
+		ClientMsgID: message.ClientMsgID,
+		RoomID:      message.RoomID,
+		Error:       reason,
+	})
+}
+
+// targets returns the connections a join or leave applies to and must be
+// called with h.mu held.
+func (h *Hub) targets(sender *client.Client, allDevices bool) []*client.Client {
+	if !allDevices {
+		return []*client.Client{sender}
+	}
+
+	targets := make([]*client.Client, 0, len(h.users[sender.UserID]))
+	for _, c := range h.users[sender.UserID] {
+		targets = append(targets, c)
+	}
+	return targets
+}
+
+// userInRoom reports whether any of the user's connections on this node is a
+// member of the room. It must be called with h.mu held.
+func (h *Hub) userInRoom(userID, roomID string) bool {
+	for _, c := range h.users[userID] {
+		if c.Rooms[roomID] {
+			return true
+		}
+	}
+	return false
+}
+
+// addToRoom must be called with h.mu held. The node subscribes to the room's
+// channel when its first local member joins.
+func (h *Hub) addToRoom(roomID string, c *client.Client) {
+	if h.rooms[roomID] == nil {
+		h.rooms[roomID] = make(map[uuid.UUID]*client.Client)
+		h.subscription.Add(roomChannel(roomID))
+	}
+	h.rooms[roomID][c.ID] = c
+	c.Rooms[roomID] = true
+}
+
+// removeFromRoom must be called with h.mu held. It reports whether the client
+// was a member, and stops listening on the room's channel once the last local
+// member is gone.
+func (h *Hub) removeFromRoom(roomID string, c *client.Client) bool {
+	delete(c.Rooms, roomID)
+	delete(h.replays, replayKey{clientID: c.ID, roomID: roomID})
+
 	room, exists := h.rooms[roomID]
-	h.mu.Unlock()
+	if _, member := room[c.ID]; !exists || !member {
+		return false
+	}
 
-	if !exists {
+	delete(room, c.ID)
+	if len(room) == 0 {
+		delete(h.rooms, roomID)
+		h.subscription.Remove(roomChannel(roomID))
+	}
+	return true
+}
+
+// deliver sends an event to local room members and to the other nodes.
+func (h *Hub) deliver(event *models.WebSocketMessage) {
+	if event.Type == "moderation" {
+		h.deliverModeration(event)
 		return
 	}
-	for client := range room {
+
+	h.broadcastToRoom(event.RoomID, event, nil)
+	h.publish(event)
+
+	if event.Type == "room_deleted" {
+		h.closeRoom(event.RoomID)
+	}
+}
+
+// deliverToUser sends an event to the user's connections on every node.
+func (h *Hub) deliverToUser(userID string, event *models.WebSocketMessage) {
+	h.applyUserEvent(userID, event)
+	h.publishToUser(userID, event)
+}
+
+// deliverMentions tells every mentioned user about the message. @room in a
+// large room means a publish per participant, so this runs in its own
+// goroutine rather than holding up the hub; it only reads the connection
+// index.
+func (h *Hub) deliverMentions(message *models.Message) {
+	for _, mention := range message.Mentions {
+		userID := mention.UserID.String()
+		event := models.NewMentionEvent(message, mention.Kind)
+
+		for _, c := range h.userConnections(userID) {
+			c.SendMessage(event)
+		}
+		h.publishToUser(userID, event)
+	}
+}
+
+// userConnections returns a snapshot of the user's local connections.
+func (h *Hub) userConnections(userID string) []*client.Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	connections := make([]*client.Client, 0, len(h.users[userID]))
+	for _, c := range h.users[userID] {
+		connections = append(connections, c)
+	}
+	return connections
+}
+
+// handleRemote delivers a message another node published.
+func (h *Hub) handleRemote(envelope *models.Envelope) {
+	message := envelope.Message
+	switch {
+	case envelope.UserID != "":
+		h.applyUserEvent(envelope.UserID, message)
+	case message.Type == "moderation":
+		h.broadcastToOthers(message.RoomID, message, message.UserID)
+	case message.Type == "room_deleted":
+		h.broadcastToRoom(message.RoomID, message, nil)
+		h.closeRoom(message.RoomID)
+	default:
+		h.broadcastToRoom(message.RoomID, message, nil)
+	}
+}
+
+// closeRoom drops every local member of a deleted room, which also stops this
+// node listening on its channel. The connections themselves stay open.
+func (h *Hub) closeRoom(roomID string) {
+	h.mu.Lock()
+	members := make([]*client.Client, 0, len(h.rooms[roomID]))
+	for _, c := range h.rooms[roomID] {
+		members = append(members, c)
+	}
+	for _, c := range members {
+		h.removeFromRoom(roomID, c)
+	}
+	h.mu.Unlock()
+
+	for _, c := range members {
+		h.clearTyping(typingKey{roomID: roomID, userID: c.UserID})
+	}
+}
+
+func (h *Hub) broadcastToRoom(roomID string, message *models.WebSocketMessage, except *client.Client) {
+	for _, client := range h.roomMembers(roomID) {
 		if client != except {
-			client.SendMessage(message)
+			h.sendToMember(roomID, client, message)
 		}
 	}
 }
 
-func (h *Hub) publishToRedis(message *models.WebSocketMessage) {
-	data, err := json.Marshal(message)
+// roomMembers returns a snapshot of the room's local connections so callers
+// can send to them without holding h.mu.
+func (h *Hub) roomMembers(roomID string) []*client.Client {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	members := make([]*client.Client, 0, len(h.rooms[roomID]))
+	for _, c := range h.rooms[roomID] {
+		members = append(members, c)
+	}
+	return members
+}
+
+func (h *Hub) publish(message *models.WebSocketMessage) {
+	h.publishEnvelope(roomChannel(message.RoomID), &models.Envelope{NodeID: h.nodeID, Message: message})
+}
+
+// publishToUser sends message to the user's connections on the other nodes.
+func (h *Hub) publishToUser(userID string, message *models.WebSocketMessage) {
+	h.publishEnvelope(userChannel(userID), &models.Envelope{NodeID: h.nodeID, UserID: userID, Message: message})
+}
+
+func (h *Hub) publishEnvelope(channel string, envelope *models.Envelope) {
+	data, err := json.Marshal(envelope)
 	if err != nil {
-		h.logger.Error("Failed to marshal message for Redis", "error", err)
+		h.logger.Error("Failed to marshal message for broker", "error", err)
 		return
 	}
 
-	if err := h.redisPubSub.Publish(h.ctx, "chat.messages", string(data)); err != nil {
-		h.logger.Error("Failed to publish to Redis", "error", err)
+	if err := h.broker.Publish(h.ctx, channel, string(data)); err != nil {
+		h.logger.Error("Failed to publish to broker", "error", err)
 	}
 }
 
-func (h *Hub) subscribeToRedis() {
-	msgChan := h.redisPubSub.Subscribe(h.ctx, "chat.messages")
+// consume hands messages from other nodes to the hub. The node listens on a
+// room's channel while it has members in it and on a user's channel while
+// that user is connected to it, all through the one subscription.
+func (h *Hub) consume() {
+	for msg := range h.subscription.Messages() {
+		var envelope models.Envelope
+		if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil || envelope.Message == nil {
+			h.logger.Error("Failed to unmarshal broker message", "channel", msg.Topic, "error", err)
+			continue
+		}
+
+		// Our own publishes were already delivered locally.
+		if envelope.NodeID == h.nodeID {
+			continue
+		}
 
-	for {
 		select {
+		case h.remote <- &envelope:
 		case <-h.ctx.Done():
 			return
-		case msg, ok := <-msgChan:
-			if !ok {
-				h.logger.Warn("Redis message channel closed")
-				return
-			}
-
-			var wsMsg models.WebSocketMessage
-			if err := json.Unmarshal([]byte(msg), &wsMsg); err != nil {
-				h.logger.Error("Failed to unmarshal Redis message", "error", err)
-				continue
-			}
-
-			h.broadcastToRoom(wsMsg.RoomID, &wsMsg, nil)
 		}
 	}
+
+	if h.ctx.Err() == nil {
+		h.logger.Warn("Broker subscription closed")
+	}
 }
 
+// Shutdown closes every local connection and takes it out of presence before
+// stopping the hub, so users who were only connected here go offline now
+// rather than when their presence entries expire.
 func (h *Hub) Shutdown() {
-	h.cancel()
+	h.mu.RLock()
+	clients := make([]*client.Client, 0, len(h.clients))
+	for _, c := range h.clients {
+		clients = append(clients, c)
+	}
+	h.mu.RUnlock()
 
-	h.mu.Lock()
-	for client := range h.clients {
-		client.Close()
+	for _, c := range clients {
+		c.Close()
+		h.trackDisconnect(c)
 	}
-	h.mu.Unlock()
+
+	h.cancel()
 
 	h.logger.Info("Hub shutdown complete")
 }
+
+func roomChannel(roomID string) string {
+	return roomChannelPrefix + roomID
+}
+
+func userChannel(userID string) string {
+	return userChannelPrefix + userID
+}
@@ -0,0 +1,420 @@
+package hub
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/client"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/service"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/broker"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/presence"
+	"github.com/dmehra2102/go-realtime-chat/shared/pkg/logger"
+	"github.com/google/uuid"
+)
+
+// frameTimeout bounds waiting for a frame that should arrive; quietPeriod is
+// how long a connection must stay silent to count as having received nothing.
+const (
+	frameTimeout = time.Second
+	quietPeriod  = 50 * time.Millisecond
+)
+
+var testLogger = &logger.Logger{Logger: slog.New(slog.NewTextHandler(io.Discard, nil))}
+
+// fakeChatService keeps messages in memory and lets anyone join any room.
+// Methods the hub tests do not use fall through to the nil embedded interface
+// and panic.
+type fakeChatService struct {
+	service.ChatService
+
+	mu       sync.Mutex
+	messages map[string][]*models.Message
+	// beforeReplay and afterReplay, when set, run once inside the next
+	// GetMessagesAfter, before and after it reads; they stand in for the
+	// time a real query takes.
+	beforeReplay func()
+	afterReplay  func()
+}
+
+func newFakeChatService() *fakeChatService {
+	return &fakeChatService{messages: make(map[string][]*models.Message)}
+}
+
+func (f *fakeChatService) JoinRoom(roomID, userID, username string) error {
+	return nil
+}
+
+func (f *fakeChatService) GetUserRoomIDs(userID string) ([]string, error) {
+	return nil, nil
+}
+
+func (f *fakeChatService) SaveMessage(ctx context.Context, msg *models.WebSocketMessage) (*models.Message, bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if msg.ClientMsgID != "" {
+		for _, m := range f.messages[msg.RoomID] {
+			if m.UserID.String() == msg.UserID && m.ClientMsgID != nil && *m.ClientMsgID == msg.ClientMsgID {
+				return m, false, nil
+			}
+		}
+	}
+
+	m := &models.Message{
+		ID:        uuid.New(),
+		RoomID:    uuid.MustParse(msg.RoomID),
+		UserID:    uuid.MustParse(msg.UserID),
+		Username:  msg.Username,
+		Content:   msg.Content,
+		Seq:       int64(len(f.messages[msg.RoomID]) + 1),
+		CreatedAt: time.Now(),
+	}
+	if msg.ClientMsgID != "" {
+		clientMsgID := msg.ClientMsgID
+		m.ClientMsgID = &clientMsgID
+	}
+	f.messages[msg.RoomID] = append(f.messages[msg.RoomID], m)
+	return m, true, nil
+}
+
+func (f *fakeChatService) GetMessagesAfter(roomID string, afterSeq int64, limit int) ([]*models.Message, error) {
+	f.runHook(&f.beforeReplay)
+
+	f.mu.Lock()
+	var messages []*models.Message
+	for _, m := range f.messages[roomID] {
+		if m.Seq > afterSeq && len(messages) < limit {
+			messages = append(messages, m)
+		}
+	}
+	f.mu.Unlock()
+
+	f.runHook(&f.afterReplay)
+	return messages, nil
+}
+
+func (f *fakeChatService) runHook(hook *func()) {
+	f.mu.Lock()
+	run := *hook
+	*hook = nil
+	f.mu.Unlock()
+
+	if run != nil {
+		run()
+	}
+}
+
+// seed stores n messages in the room without going through the hub.
+func (f *fakeChatService) seed(roomID string, n int) {
+	userID := uuid.NewString()
+	for range n {
+		f.SaveMessage(context.Background(), &models.WebSocketMessage{RoomID: roomID, UserID: userID, Username: "seed", Content: "old"})
+	}
+}
+
+func (f *fakeChatService) Moderate(roomID, userID, targetID, action string, req *models.ModerationRequest) (*models.ModerationLog, error) {
+	return &models.ModerationLog{
+		RoomID:    uuid.MustParse(roomID),
+		ActorID:   uuid.MustParse(userID),
+		TargetID:  uuid.MustParse(targetID),
+		Action:    action,
+		Reason:    req.Reason,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// newTestHub runs a hub on b, which other test hubs may share to stand in for
+// other nodes.
+func newTestHub(t *testing.T, b broker.Broker, chatService service.ChatService) *Hub {
+	t.Helper()
+
+	h := NewHub(b, presence.NewMemoryTracker(presence.DefaultTTL), chatService, uuid.NewString(), testLogger)
+	go h.Run()
+	t.Cleanup(h.Shutdown)
+	return h
+}
+
+// connect registers a connection the way the websocket handler does, minus
+// the pumps: tests read what the hub sends straight from Send.
+func connect(h *Hub, userID, username string) *client.Client {
+	c := client.NewClient(userID, username, h, nil, testLogger)
+	h.Register(c)
+	return c
+}
+
+// send hands the hub a frame from c, stamped with its user as ReadPump does.
+func send(h *Hub, c *client.Client, frame *models.WebSocketMessage) {
+	frame.UserID = c.UserID
+	frame.Username = c.Username
+	h.Broadcast(c, frame)
+}
+
+// settle returns once the hub has handled everything sent to it so far. The
+// hub ignores unknown frame types, and it only takes the next frame after it
+// is done with the previous one.
+func settle(h *Hub) {
+	h.Broadcast(nil, &models.WebSocketMessage{Type: "settle"})
+	h.Broadcast(nil, &models.WebSocketMessage{Type: "settle"})
+}
+
+// join puts c in the room, which this node then listens on, and discards the
+// join frames the room's other members got.
+func join(t *testing.T, h *Hub, c *client.Client, roomID string, others ...*client.Client) {
+	t.Helper()
+
+	send(h, c, &models.WebSocketMessage{Type: "join", RoomID: roomID})
+	settle(h)
+	for _, other := range others {
+		expectFrame(t, other, "join")
+	}
+}
+
+func nextFrame(t *testing.T, c *client.Client) *models.WebSocketMessage {
+	t.Helper()
+
+	select {
+	case data := <-c.Send:
+		var frame models.WebSocketMessage
+		if err := json.Unmarshal(data, &frame); err != nil {
+			t.Fatalf("undecodable frame %s: %v", data, err)
+		}
+		return &frame
+	case <-time.After(frameTimeout):
+		t.Fatalf("%s received nothing", c.Username)
+		return nil
+	}
+}
+
+func expectFrame(t *testing.T, c *client.Client, frameType string) *models.WebSocketMessage {
+	t.Helper()
+
+	frame := nextFrame(t, c)
+	if frame.Type != frameType {
+		t.Fatalf("%s got a %q frame %+v, want %q", c.Username, frame.Type, frame, frameType)
+	}
+	return frame
+}
+
+func expectNothing(t *testing.T, c *client.Client) {
+	t.Helper()
+
+	select {
+	case data := <-c.Send:
+		t.Fatalf("%s got %s, want nothing", c.Username, data)
+	case <-time.After(quietPeriod):
+	}
+}
+
+func TestHubDeliversAcrossNodes(t *testing.T) {
+	b := broker.NewMemoryBroker()
+	nodeA := newTestHub(t, b, newFakeChatService())
+	nodeB := newTestHub(t, b, newFakeChatService())
+	roomID := uuid.NewString()
+
+	alice := connect(nodeA, uuid.NewString(), "alice")
+	bob := connect(nodeB, uuid.NewString(), "bob")
+	join(t, nodeA, alice, roomID)
+	join(t, nodeB, bob, roomID, alice)
+
+	send(nodeA, alice, &models.WebSocketMessage{Type: "message", RoomID: roomID, Content: "hello"})
+
+	expectFrame(t, alice, "ack")
+	own := expectFrame(t, alice, "message")
+	remote := expectFrame(t, bob, "message")
+	if remote.ID != own.ID || remote.Content != "hello" || remote.Username != "alice" {
+		t.Errorf("bob got %+v, want alice's message %+v", remote, own)
+	}
+
+	// A node skips its own publishes on the way back from the broker.
+	expectNothing(t, alice)
+}
+
+func TestMessageAckAndDedupe(t *testing.T) {
+	h := newTestHub(t, broker.NewMemoryBroker(), newFakeChatService())
+	roomID := uuid.NewString()
+
+	alice := connect(h, uuid.NewString(), "alice")
+	bob := connect(h, uuid.NewString(), "bob")
+	join(t, h, alice, roomID)
+	join(t, h, bob, roomID, alice)
+
+	send(h, alice, &models.WebSocketMessage{Type: "message", RoomID: roomID, ClientMsgID: "c1", Content: "hello"})
+
+	ack := expectFrame(t, alice, "ack")
+	if ack.ClientMsgID != "c1" || ack.ID == "" || ack.Seq != 1 || ack.Timestamp == nil {
+		t.Fatalf("ack = %+v, want client_msg_id c1 with the stored ID, seq 1 and timestamp", ack)
+	}
+	expectFrame(t, alice, "message")
+	delivered := expectFrame(t, bob, "message")
+	if delivered.ID != ack.ID || delivered.Seq != ack.Seq {
+		t.Errorf("bob got %+v, want the acked message", delivered)
+	}
+
+	// The retry is acked with the original message and not delivered again.
+	send(h, alice, &models.WebSocketMessage{Type: "message", RoomID: roomID, ClientMsgID: "c1", Content: "hello"})
+
+	retry := expectFrame(t, alice, "ack")
+	if retry.ID != ack.ID || retry.Seq != ack.Seq {
+		t.Errorf("retry ack = %+v, want %+v", retry, ack)
+	}
+	expectNothing(t, alice)
+	expectNothing(t, bob)
+
+	// A new client_msg_id is a new message.
+	send(h, alice, &models.WebSocketMessage{Type: "message", RoomID: roomID, ClientMsgID: "c2", Content: "again"})
+
+	if next := expectFrame(t, alice, "ack"); next.Seq != 2 || next.ID == ack.ID {
+		t.Errorf("ack = %+v, want a new message with seq 2", next)
+	}
+	expectFrame(t, bob, "message")
+}
+
+func TestMessageBroadcastCarriesOnlyStoredFields(t *testing.T) {
+	h := newTestHub(t, broker.NewMemoryBroker(), newFakeChatService())
+	roomID := uuid.NewString()
+
+	alice := connect(h, uuid.NewString(), "alice")
+	bob := connect(h, uuid.NewString(), "bob")
+	join(t, h, alice, roomID)
+	join(t, h, bob, roomID, alice)
+
+	send(h, alice, &models.WebSocketMessage{
+		Type:       "message",
+		RoomID:     roomID,
+		Content:    "hello",
+		Thread:     true,
+		LastSeq:    42,
+		Status:     "away",
+		Emoji:      "👍",
+		AllDevices: true,
+		TargetID:   bob.UserID,
+		Reason:     "spoofed",
+		Error:      "spoofed",
+		Data:       map[string]any{"action": "ban"},
+	})
+
+	got := expectFrame(t, bob, "message")
+	want := &models.WebSocketMessage{
+		Type:      "message",
+		ID:        got.ID,
+		RoomID:    roomID,
+		Seq:       1,
+		UserID:    alice.UserID,
+		Username:  "alice",
+		Content:   "hello",
+		Timestamp: got.Timestamp,
+	}
+	gotJSON, _ := json.Marshal(got)
+	wantJSON, _ := json.Marshal(want)
+	if string(gotJSON) != string(wantJSON) {
+		t.Errorf("bob got %s, want %s", gotJSON, wantJSON)
+	}
+}
+
+func TestLeaveWithMultipleTabs(t *testing.T) {
+	tests := []struct {
+		name  string
+		leave func(h *Hub, first, second *client.Client, roomID string)
+	}{
+		{
+			name: "leave frames",
+			leave: func(h *Hub, first, second *client.Client, roomID string) {
+				send(h, first, &models.WebSocketMessage{Type: "leave", RoomID: roomID})
+				settle(h)
+				send(h, second, &models.WebSocketMessage{Type: "leave", RoomID: roomID})
+			},
+		},
+		{
+			name: "disconnects",
+			leave: func(h *Hub, first, second *client.Client, roomID string) {
+				h.Unregister(first)
+				settle(h)
+				h.Unregister(second)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHub(t, broker.NewMemoryBroker(), newFakeChatService())
+			roomID := uuid.NewString()
+
+			aliceID := uuid.NewString()
+			laptop := connect(h, aliceID, "alice")
+			phone := connect(h, aliceID, "alice")
+			bob := connect(h, uuid.NewString(), "bob")
+			join(t, h, bob, roomID)
+			join(t, h, laptop, roomID, bob)
+			join(t, h, phone, roomID, bob, laptop)
+
+			tt.leave(h, laptop, phone, roomID)
+
+			// Only the second tab going away takes alice out of the room.
+			leave := expectFrame(t, bob, "leave")
+			if leave.UserID != aliceID {
+				t.Errorf("leave for %q, want alice", leave.UserID)
+			}
+			expectNothing(t, bob)
+		})
+	}
+}
+
+func TestLeaveAllDevices(t *testing.T) {
+	h := newTestHub(t, broker.NewMemoryBroker(), newFakeChatService())
+	roomID := uuid.NewString()
+
+	aliceID := uuid.NewString()
+	laptop := connect(h, aliceID, "alice")
+	phone := connect(h, aliceID, "alice")
+	bob := connect(h, uuid.NewString(), "bob")
+	join(t, h, bob, roomID)
+	join(t, h, laptop, roomID, bob)
+	join(t, h, phone, roomID, bob, laptop)
+
+	send(h, laptop, &models.WebSocketMessage{Type: "leave", RoomID: roomID, AllDevices: true})
+
+	expectFrame(t, bob, "leave")
+	expectNothing(t, bob)
+
+	send(h, bob, &models.WebSocketMessage{Type: "message", RoomID: roomID, Content: "still there?"})
+	expectFrame(t, bob, "ack")
+	expectFrame(t, bob, "message")
+	expectNothing(t, laptop)
+	expectNothing(t, phone)
+}
+
+func TestMessageOutsideRoomIsRejected(t *testing.T) {
+	h := newTestHub(t, broker.NewMemoryBroker(), newFakeChatService())
+
+	alice := connect(h, uuid.NewString(), "alice")
+	send(h, alice, &models.WebSocketMessage{Type: "message", RoomID: uuid.NewString(), ClientMsgID: "c1", Content: "hello"})
+
+	rejected := expectFrame(t, alice, "error")
+	if rejected.ClientMsgID != "c1" || rejected.Error != service.ErrNotInRoom.Error() {
+		t.Errorf("error = %+v, want %q for c1", rejected, service.ErrNotInRoom)
+	}
+}
+
+func TestLeaveUnjoinedRoomIsIgnored(t *testing.T) {
+	b := broker.NewMemoryBroker()
+	h := newTestHub(t, b, newFakeChatService())
+	other := newTestHub(t, b, newFakeChatService())
+	roomID := uuid.NewString()
+
+	bob := connect(h, uuid.NewString(), "bob")
+	carol := connect(other, uuid.NewString(), "carol")
+	join(t, h, bob, roomID)
+	join(t, other, carol, roomID, bob)
+
+	mallory := connect(h, uuid.NewString(), "mallory")
+	send(h, mallory, &models.WebSocketMessage{Type: "leave", RoomID: roomID})
+
+	expectNothing(t, bob)
+	expectNothing(t, carol)
+}
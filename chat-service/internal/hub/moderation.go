@@ -0,0 +1,73 @@
+package hub
+
+import (
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/client"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/service"
+)
+
+// handleModeration applies a kick, ban or mute frame. TargetID names the
+// user the action is against.
+func (h *Hub) handleModeration(sender *client.Client, message *models.WebSocketMessage) {
+	req := &models.ModerationRequest{Reason: message.Reason, Duration: message.Duration}
+
+	entry, err := h.chatService.Moderate(message.RoomID, sender.UserID, message.TargetID, message.Type, req)
+	if err != nil {
+		h.logger.Warn("Moderation rejected", "userID", sender.UserID, "roomID", message.RoomID, "action", message.Type, "error", err)
+		h.sendError(sender, message, service.ErrorMessage(err, "failed to moderate user"))
+		return
+	}
+
+	h.deliverModeration(models.NewModerationEvent(entry))
+
+	h.logger.Info("Moderation applied", "roomID", message.RoomID, "actorID", sender.UserID, "targetID", message.TargetID, "action", message.Type)
+}
+
+// deliverModeration tells the room what happened and the target, on every
+// node, through their own channel. The target is left out of the room
+// broadcast so their connections see the event once, and those connections
+// can be told even when they have not joined the room.
+func (h *Hub) deliverModeration(event *models.WebSocketMessage) {
+	h.broadcastToOthers(event.RoomID, event, event.UserID)
+	h.publish(event)
+
+	h.deliverToUser(event.UserID, event)
+}
+
+// applyUserEvent sends a targeted event to the user's local connections.
+// Kicks and bans also take those connections out of the room.
+func (h *Hub) applyUserEvent(userID string, event *models.WebSocketMessage) {
+	h.mu.Lock()
+	connections := make([]*client.Client, 0, len(h.users[userID]))
+	evicted, username := false, ""
+	for _, c := range h.users[userID] {
+		connections = append(connections, c)
+
+		if event.Type == "moderation" && isRemoval(event) && h.removeFromRoom(event.RoomID, c) {
+			evicted, username = true, c.Username
+		}
+	}
+	h.mu.Unlock()
+
+	for _, c := range connections {
+		c.SendMessage(event)
+	}
+
+	if evicted {
+		h.stopTyping(typingKey{roomID: event.RoomID, userID: userID}, username)
+	}
+}
+
+func (h *Hub) broadcastToOthers(roomID string, message *models.WebSocketMessage, userID string) {
+	for _, c := range h.roomMembers(roomID) {
+		if c.UserID != userID {
+			h.sendToMember(roomID, c, message)
+		}
+	}
+}
+
+func isRemoval(event *models.WebSocketMessage) bool {
+	data, _ := event.Data.(map[string]any)
+	action, _ := data["action"].(string)
+	return action == models.ModerationKick || action == models.ModerationBan
+}
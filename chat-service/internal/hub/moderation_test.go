@@ -0,0 +1,53 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/client"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/service"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/broker"
+	"github.com/google/uuid"
+)
+
+func TestKickEvictsEveryConnection(t *testing.T) {
+	b := broker.NewMemoryBroker()
+	nodeA := newTestHub(t, b, newFakeChatService())
+	nodeB := newTestHub(t, b, newFakeChatService())
+	roomID := uuid.NewString()
+
+	aliceID := uuid.NewString()
+	mod := connect(nodeA, uuid.NewString(), "mod")
+	laptop := connect(nodeA, aliceID, "alice")
+	phone := connect(nodeB, aliceID, "alice")
+	bob := connect(nodeB, uuid.NewString(), "bob")
+	join(t, nodeA, mod, roomID)
+	join(t, nodeA, laptop, roomID, mod)
+	join(t, nodeB, phone, roomID, mod, laptop)
+	join(t, nodeB, bob, roomID, phone, mod, laptop)
+
+	send(nodeA, mod, &models.WebSocketMessage{Type: models.ModerationKick, RoomID: roomID, TargetID: aliceID, Reason: "spam"})
+
+	// Everyone hears about it once, alice's connections on both nodes
+	// through her own channel.
+	for _, c := range []*client.Client{mod, laptop, phone, bob} {
+		event := expectFrame(t, c, "moderation")
+		if event.UserID != aliceID || !isRemoval(event) || event.Reason != "spam" {
+			t.Errorf("%s got %+v, want alice's kick", c.Username, event)
+		}
+	}
+
+	// Neither of alice's connections is in the room any more.
+	send(nodeB, bob, &models.WebSocketMessage{Type: "message", RoomID: roomID, Content: "bye"})
+	expectFrame(t, bob, "ack")
+	expectFrame(t, bob, "message")
+	expectFrame(t, mod, "message")
+	expectNothing(t, laptop)
+	expectNothing(t, phone)
+
+	send(nodeB, phone, &models.WebSocketMessage{Type: "message", RoomID: roomID, Content: "let me back"})
+	if rejected := expectFrame(t, phone, "error"); rejected.Error != service.ErrNotInRoom.Error() {
+		t.Errorf("error = %q, want %q", rejected.Error, service.ErrNotInRoom)
+	}
+	expectNothing(t, bob)
+}
@@ -0,0 +1,93 @@
+package hub
+
+import (
+	"context"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/client"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/presence"
+)
+
+const presenceTimeout = 3 * time.Second
+
+// RefreshPresence is called from the client's ping loop and keeps its
+// connection from expiring in the presence store.
+func (h *Hub) RefreshPresence(c *client.Client) {
+	ctx, cancel := context.WithTimeout(h.ctx, presenceTimeout)
+	defer cancel()
+
+	if err := h.presence.Refresh(ctx, c.UserID, c.ID.String()); err != nil {
+		h.logger.Error("Failed to refresh presence", "userID", c.UserID, "error", err)
+	}
+}
+
+func (h *Hub) trackConnect(c *client.Client) {
+	ctx, cancel := context.WithTimeout(h.ctx, presenceTimeout)
+	defer cancel()
+
+	first, err := h.presence.Connect(ctx, c.UserID, c.ID.String())
+	if err != nil {
+		h.logger.Error("Failed to record presence", "userID", c.UserID, "error", err)
+		return
+	}
+
+	if first {
+		h.announcePresence(c.UserID, c.Username, presence.StatusOnline)
+	}
+}
+
+func (h *Hub) trackDisconnect(c *client.Client) {
+	ctx, cancel := context.WithTimeout(h.ctx, presenceTimeout)
+	defer cancel()
+
+	last, err := h.presence.Disconnect(ctx, c.UserID, c.ID.String())
+	if err != nil {
+		h.logger.Error("Failed to clear presence", "userID", c.UserID, "error", err)
+		return
+	}
+
+	if last {
+		h.announcePresence(c.UserID, c.Username, presence.StatusOffline)
+	}
+}
+
+func (h *Hub) handlePresence(sender *client.Client, message *models.WebSocketMessage) {
+	if !presence.IsSettable(message.Status) {
+		h.sendError(sender, message, "invalid presence status")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(h.ctx, presenceTimeout)
+	defer cancel()
+
+	if err := h.presence.SetStatus(ctx, sender.UserID, message.Status); err != nil {
+		h.logger.Error("Failed to set presence status", "userID", sender.UserID, "error", err)
+		h.sendError(sender, message, "failed to set presence status")
+		return
+	}
+
+	h.announcePresence(sender.UserID, sender.Username, message.Status)
+}
+
+// announcePresence tells every room the user belongs to about the change,
+// whether or not the user has joined those rooms on this connection.
+func (h *Hub) announcePresence(userID, username, status string) {
+	roomIDs, err := h.chatService.GetUserRoomIDs(userID)
+	if err != nil {
+		h.logger.Error("Failed to load rooms for presence", "userID", userID, "error", err)
+		return
+	}
+
+	for _, roomID := range roomIDs {
+		event := &models.WebSocketMessage{
+			Type:     "presence",
+			RoomID:   roomID,
+			UserID:   userID,
+			Username: username,
+			Status:   status,
+		}
+		h.broadcastToRoom(roomID, event, nil)
+		h.publish(event)
+	}
+}
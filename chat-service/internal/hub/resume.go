@@ -0,0 +1,167 @@
+package hub
+
+import (
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/client"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/service"
+	"github.com/google/uuid"
+)
+
+const (
+	replayPageSize = 100
+	// maxReplay stays below the client's send buffer; anything older is
+	// fetched through the history API instead.
+	maxReplay = 200
+)
+
+type replayKey struct {
+	clientID uuid.UUID
+	roomID   string
+}
+
+// replay is a resume in progress. Room events for the connection are held
+// back until the replay is done so the client sees them after the history
+// they follow.
+type replay struct {
+	message *models.WebSocketMessage
+	joined  bool
+	held    []*models.WebSocketMessage
+}
+
+type replayResult struct {
+	client    *client.Client
+	replay    *replay
+	lastSeq   int64
+	truncated bool
+	err       error
+}
+
+// Replays in progress are only touched from the Run goroutine; the queries
+// run in their own goroutine and report back through h.replayed.
+
+// handleResume puts a reconnecting client back into a room and replays what
+// it missed since message.LastSeq. The client joins the room before the
+// replay query runs, so nothing falls between the two; live events are held
+// until the replay finishes and those it already covered are dropped.
+func (h *Hub) handleResume(sender *client.Client, message *models.WebSocketMessage) {
+	key := replayKey{clientID: sender.ID, roomID: message.RoomID}
+	if h.replays[key] != nil {
+		h.sendError(sender, message, "resume already in progress")
+		return
+	}
+
+	if err := h.chatService.JoinRoom(message.RoomID, sender.UserID, sender.Username); err != nil {
+		h.logger.Warn("Resume rejected", "userID", sender.UserID, "roomID", message.RoomID, "error", err)
+		h.sendError(sender, message, service.ErrorMessage(err, "failed to resume room"))
+		return
+	}
+
+	h.mu.Lock()
+	joined := !sender.Rooms[message.RoomID]
+	if joined {
+		h.addToRoom(message.RoomID, sender)
+	}
+	h.mu.Unlock()
+
+	r := &replay{message: message, joined: joined}
+	h.replays[key] = r
+
+	go h.runReplay(sender, r)
+}
+
+// runReplay sends the missed messages straight to the client, off the hub
+// goroutine, and then hands the outcome back to the hub.
+func (h *Hub) runReplay(sender *client.Client, r *replay) {
+	result := &replayResult{client: sender, replay: r, lastSeq: r.message.LastSeq}
+
+	for replayed := 0; ; {
+		limit := min(replayPageSize, maxReplay-replayed)
+
+		// One extra row tells whether anything is left after this page.
+		messages, err := h.chatService.GetMessagesAfter(r.message.RoomID, result.lastSeq, limit+1)
+		if err != nil {
+			result.err = err
+			break
+		}
+
+		more := len(messages) > limit
+		if more {
+			messages = messages[:limit]
+		}
+
+		for _, m := range messages {
+			sender.SendMessage(models.NewHistoryEvent(m))
+			result.lastSeq = m.Seq
+		}
+		replayed += len(messages)
+
+		if !more {
+			break
+		}
+		if replayed >= maxReplay {
+			result.truncated = true
+			break
+		}
+	}
+
+	select {
+	case h.replayed <- result:
+	case <-h.ctx.Done():
+	}
+}
+
+// finishResume releases the events held during the replay. A result whose
+// replay is no longer registered belongs to a connection that has since left
+// the room and is dropped.
+func (h *Hub) finishResume(result *replayResult) {
+	sender, r, message := result.client, result.replay, result.replay.message
+
+	key := replayKey{clientID: sender.ID, roomID: message.RoomID}
+	if h.replays[key] != r {
+		return
+	}
+	delete(h.replays, key)
+
+	if result.err != nil {
+		h.logger.Error("Failed to replay messages", "roomID", message.RoomID, "error", result.err)
+		h.sendError(sender, message, service.ErrorMessage(result.err, "failed to replay messages"))
+	} else {
+		// A truncated replay tells the client to page the rest from history.
+		sender.SendMessage(&models.WebSocketMessage{
+			Type:    "resumed",
+			RoomID:  message.RoomID,
+			LastSeq: result.lastSeq,
+			Data:    map[string]bool{"truncated": result.truncated},
+		})
+	}
+
+	for _, event := range r.held {
+		if event.Type == "message" && event.Seq <= result.lastSeq {
+			continue
+		}
+		sender.SendMessage(event)
+	}
+
+	if r.joined {
+		joinMsg := &models.WebSocketMessage{
+			Type:     "join",
+			RoomID:   message.RoomID,
+			UserID:   sender.UserID,
+			Username: sender.Username,
+		}
+		h.broadcastToRoom(message.RoomID, joinMsg, sender)
+		h.publish(joinMsg)
+	}
+
+	h.logger.Info("Client resumed room", "userID", sender.UserID, "roomID", message.RoomID, "lastSeq", result.lastSeq)
+}
+
+// sendToMember delivers a room event to one local member, or holds it while
+// that member is still replaying the room.
+func (h *Hub) sendToMember(roomID string, c *client.Client, message *models.WebSocketMessage) {
+	if r := h.replays[replayKey{clientID: c.ID, roomID: roomID}]; r != nil {
+		r.held = append(r.held, message)
+		return
+	}
+	c.SendMessage(message)
+}
@@ -0,0 +1,97 @@
+package hub
+
+import (
+	"testing"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/broker"
+	"github.com/google/uuid"
+)
+
+func TestResumeHoldsLiveEventsUntilReplayEnds(t *testing.T) {
+	chat := newFakeChatService()
+	h := newTestHub(t, broker.NewMemoryBroker(), chat)
+	roomID := uuid.NewString()
+	chat.seed(roomID, 3)
+
+	alice := connect(h, uuid.NewString(), "alice")
+	carol := connect(h, uuid.NewString(), "carol")
+	join(t, h, alice, roomID)
+
+	// Seq 4 lands before the replay reads, so the replay covers it and the
+	// held live copy is dropped. Seq 5 lands after, so only the held copy
+	// has it. The typing frame is not history and always goes through.
+	chat.beforeReplay = func() {
+		send(h, alice, &models.WebSocketMessage{Type: "message", RoomID: roomID, Content: "four"})
+		send(h, alice, &models.WebSocketMessage{Type: "typing_start", RoomID: roomID})
+		settle(h)
+	}
+	chat.afterReplay = func() {
+		send(h, alice, &models.WebSocketMessage{Type: "message", RoomID: roomID, Content: "five"})
+		settle(h)
+	}
+
+	send(h, carol, &models.WebSocketMessage{Type: "resume", RoomID: roomID, LastSeq: 1})
+
+	for _, seq := range []int64{2, 3, 4} {
+		if got := expectFrame(t, carol, "message"); got.Seq != seq {
+			t.Fatalf("replayed seq %d, want %d", got.Seq, seq)
+		}
+	}
+	resumed := expectFrame(t, carol, "resumed")
+	if resumed.LastSeq != 4 {
+		t.Errorf("resumed at seq %d, want 4", resumed.LastSeq)
+	}
+	expectFrame(t, carol, "typing_start")
+	if got := expectFrame(t, carol, "message"); got.Seq != 5 {
+		t.Errorf("live seq %d, want 5", got.Seq)
+	}
+	expectNothing(t, carol)
+
+	// The room hears about carol once the replay is done.
+	for _, frameType := range []string{"ack", "message", "ack", "message", "join"} {
+		expectFrame(t, alice, frameType)
+	}
+	expectNothing(t, alice)
+}
+
+func TestResumeTruncation(t *testing.T) {
+	tests := []struct {
+		name          string
+		missed        int
+		wantReplayed  int
+		wantTruncated bool
+	}{
+		{name: "nothing missed", missed: 0, wantReplayed: 0},
+		{name: "one page", missed: replayPageSize, wantReplayed: replayPageSize},
+		{name: "exactly the limit", missed: maxReplay, wantReplayed: maxReplay},
+		{name: "over the limit", missed: maxReplay + 1, wantReplayed: maxReplay, wantTruncated: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := newFakeChatService()
+			h := newTestHub(t, broker.NewMemoryBroker(), chat)
+			roomID := uuid.NewString()
+			chat.seed(roomID, tt.missed)
+
+			carol := connect(h, uuid.NewString(), "carol")
+			send(h, carol, &models.WebSocketMessage{Type: "resume", RoomID: roomID})
+
+			for i := range tt.wantReplayed {
+				if got := expectFrame(t, carol, "message"); got.Seq != int64(i+1) {
+					t.Fatalf("replayed seq %d, want %d", got.Seq, i+1)
+				}
+			}
+
+			resumed := expectFrame(t, carol, "resumed")
+			if resumed.LastSeq != int64(tt.wantReplayed) {
+				t.Errorf("resumed at seq %d, want %d", resumed.LastSeq, tt.wantReplayed)
+			}
+			data, _ := resumed.Data.(map[string]any)
+			if truncated, _ := data["truncated"].(bool); truncated != tt.wantTruncated {
+				t.Errorf("truncated = %v, want %v", truncated, tt.wantTruncated)
+			}
+		})
+	}
+}
@@ -0,0 +1,126 @@
+package hub
+
+import (
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/client"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+)
+
+// These are variables so tests can shorten them.
+var (
+	// typingThrottle is the minimum gap between two typing_start events
+	// fanned out for the same user and room.
+	typingThrottle = 3 * time.Second
+	// typingTimeout ends a typing indicator that was never stopped.
+	typingTimeout = 6 * time.Second
+)
+
+type typingKey struct {
+	roomID string
+	userID string
+}
+
+type typingState struct {
+	username  string
+	lastStart time.Time
+	timer     *time.Timer
+	gen       uint64
+}
+
+type typingExpiry struct {
+	key typingKey
+	gen uint64
+}
+
+// Typing state is only touched from the Run goroutine; timers report back
+// through h.typingExpired rather than acting on the state themselves.
+
+func (h *Hub) handleTypingStart(sender *client.Client, message *models.WebSocketMessage) {
+	if !sender.Rooms[message.RoomID] {
+		return
+	}
+
+	key := typingKey{roomID: message.RoomID, userID: sender.UserID}
+	state, typing := h.typing[key]
+	if !typing {
+		state = &typingState{username: sender.Username}
+		h.typing[key] = state
+	} else {
+		state.timer.Stop()
+	}
+
+	state.gen++
+	gen := state.gen
+	state.timer = time.AfterFunc(typingTimeout, func() {
+		select {
+		case h.typingExpired <- typingExpiry{key: key, gen: gen}:
+		case <-h.ctx.Done():
+		}
+	})
+
+	now := time.Now()
+	if typing && now.Sub(state.lastStart) < typingThrottle {
+		return
+	}
+	state.lastStart = now
+
+	h.fanOutTyping(sender, message)
+}
+
+func (h *Hub) handleTypingStop(sender *client.Client, message *models.WebSocketMessage) {
+	key := typingKey{roomID: message.RoomID, userID: sender.UserID}
+	if !h.clearTyping(key) {
+		return
+	}
+
+	h.fanOutTyping(sender, message)
+}
+
+func (h *Hub) handleTypingExpired(expiry typingExpiry) {
+	state, ok := h.typing[expiry.key]
+	if !ok || state.gen != expiry.gen {
+		return
+	}
+
+	h.stopTyping(expiry.key, state.username)
+}
+
+// stopTyping clears key and tells the room the user stopped typing. It is used
+// for timeouts and disconnects, where there is no frame from the client.
+func (h *Hub) stopTyping(key typingKey, username string) {
+	if !h.clearTyping(key) {
+		return
+	}
+
+	stopMsg := &models.WebSocketMessage{
+		Type:     "typing_stop",
+		RoomID:   key.roomID,
+		UserID:   key.userID,
+		Username: username,
+	}
+	h.broadcastToRoom(key.roomID, stopMsg, nil)
+	h.publish(stopMsg)
+}
+
+func (h *Hub) clearTyping(key typingKey) bool {
+	state, ok := h.typing[key]
+	if !ok {
+		return false
+	}
+
+	state.timer.Stop()
+	delete(h.typing, key)
+	return true
+}
+
+func (h *Hub) fanOutTyping(sender *client.Client, message *models.WebSocketMessage) {
+	event := &models.WebSocketMessage{
+		Type:     message.Type,
+		RoomID:   message.RoomID,
+		UserID:   sender.UserID,
+		Username: sender.Username,
+	}
+	h.broadcastToRoom(message.RoomID, event, sender)
+	h.publish(event)
+}
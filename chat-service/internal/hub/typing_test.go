@@ -0,0 +1,123 @@
+package hub
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/client"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/broker"
+	"github.com/google/uuid"
+)
+
+func shortenTyping(t *testing.T, throttle, timeout time.Duration) {
+	t.Helper()
+
+	oldThrottle, oldTimeout := typingThrottle, typingTimeout
+	typingThrottle, typingTimeout = throttle, timeout
+	t.Cleanup(func() {
+		typingThrottle, typingTimeout = oldThrottle, oldTimeout
+	})
+}
+
+func typingRoom(t *testing.T) (h *Hub, roomID string, alice, bob *client.Client) {
+	t.Helper()
+
+	h = newTestHub(t, broker.NewMemoryBroker(), newFakeChatService())
+	roomID = uuid.NewString()
+
+	alice = connect(h, uuid.NewString(), "alice")
+	bob = connect(h, uuid.NewString(), "bob")
+	join(t, h, alice, roomID)
+	join(t, h, bob, roomID, alice)
+	return h, roomID, alice, bob
+}
+
+func TestTypingStartIsThrottled(t *testing.T) {
+	shortenTyping(t, 100*time.Millisecond, time.Minute)
+	h, roomID, alice, bob := typingRoom(t)
+
+	start := &models.WebSocketMessage{Type: "typing_start", RoomID: roomID}
+
+	send(h, alice, start)
+	if got := expectFrame(t, bob, "typing_start"); got.UserID != alice.UserID {
+		t.Errorf("typing_start from %q, want alice", got.UserID)
+	}
+
+	send(h, alice, start)
+	send(h, alice, start)
+	expectNothing(t, bob)
+
+	time.Sleep(typingThrottle)
+	send(h, alice, start)
+	expectFrame(t, bob, "typing_start")
+
+	// The typist never hears their own indicator.
+	expectNothing(t, alice)
+}
+
+func TestTypingStopsOnTimeout(t *testing.T) {
+	shortenTyping(t, time.Minute, 100*time.Millisecond)
+	h, roomID, alice, bob := typingRoom(t)
+
+	send(h, alice, &models.WebSocketMessage{Type: "typing_start", RoomID: roomID})
+	expectFrame(t, bob, "typing_start")
+
+	stop := expectFrame(t, bob, "typing_stop")
+	if stop.UserID != alice.UserID || stop.Username != "alice" {
+		t.Errorf("typing_stop = %+v, want one for alice", stop)
+	}
+	// The server sends the stop on the typist's behalf, so they see it too.
+	expectFrame(t, alice, "typing_stop")
+
+	// Stopping after the timeout has nothing left to stop.
+	send(h, alice, &models.WebSocketMessage{Type: "typing_stop", RoomID: roomID})
+	expectNothing(t, bob)
+}
+
+func TestTypingStartExtendsTimeout(t *testing.T) {
+	shortenTyping(t, 0, 300*time.Millisecond)
+	h, roomID, alice, bob := typingRoom(t)
+
+	start := &models.WebSocketMessage{Type: "typing_start", RoomID: roomID}
+	send(h, alice, start)
+	expectFrame(t, bob, "typing_start")
+
+	time.Sleep(200 * time.Millisecond)
+	send(h, alice, start)
+	expectFrame(t, bob, "typing_start")
+
+	// The first timer would have fired by now had it not been replaced.
+	time.Sleep(150 * time.Millisecond)
+	expectNothing(t, bob)
+
+	expectFrame(t, bob, "typing_stop")
+}
+
+func TestTypingStop(t *testing.T) {
+	shortenTyping(t, time.Minute, time.Minute)
+	h, roomID, alice, bob := typingRoom(t)
+
+	stop := &models.WebSocketMessage{Type: "typing_stop", RoomID: roomID}
+
+	// Nothing to stop yet.
+	send(h, alice, stop)
+	expectNothing(t, bob)
+
+	send(h, alice, &models.WebSocketMessage{Type: "typing_start", RoomID: roomID})
+	expectFrame(t, bob, "typing_start")
+
+	send(h, alice, stop)
+	expectFrame(t, bob, "typing_stop")
+
+	send(h, alice, stop)
+	expectNothing(t, bob)
+}
+
+func TestTypingOutsideRoomIsIgnored(t *testing.T) {
+	h, roomID, _, bob := typingRoom(t)
+
+	mallory := connect(h, uuid.NewString(), "mallory")
+	send(h, mallory, &models.WebSocketMessage{Type: "typing_start", RoomID: roomID})
+	expectNothing(t, bob)
+}
@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+// ExportQuery bounds a transcript export by created_at. From is inclusive,
+// To is exclusive, and either may be nil.
+type ExportQuery struct {
+	From *time.Time
+	To   *time.Time
+}
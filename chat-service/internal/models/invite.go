@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DefaultInviteLinkTTL = 7 * 24 * time.Hour
+	MaxInviteLinkTTL     = 30 * 24 * time.Hour
+)
+
+// RoomInvitation lets a named user join a private room. It is consumed when
+// the user joins.
+type RoomInvitation struct {
+	RoomID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"room_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	InvitedBy uuid.UUID `gorm:"type:uuid;not null" json:"invited_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RoomInviteLink is a shareable token that adds whoever redeems it to the
+// room. MaxUses of zero means the link can be used until it expires.
+type RoomInviteLink struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Token     string    `gorm:"type:varchar(64);uniqueIndex;not null" json:"token"`
+	RoomID    uuid.UUID `gorm:"type:uuid;not null" json:"room_id"`
+	CreatedBy uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
+	ExpiresAt time.Time `gorm:"not null" json:"expires_at"`
+	MaxUses   int       `gorm:"not null;default:0" json:"max_uses"`
+	Uses      int       `gorm:"not null;default:0" json:"uses"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateInvitationRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+// CreateInviteLinkRequest takes the link lifetime in seconds; zero picks
+// DefaultInviteLinkTTL.
+type CreateInviteLinkRequest struct {
+	ExpiresIn int `json:"expires_in"`
+	MaxUses   int `json:"max_uses"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Mention kinds: a user named with @username, or everyone reached through
+// @here (participants online at the time) or @room (all participants).
+const (
+	MentionUser = "user"
+	MentionHere = "here"
+	MentionRoom = "room"
+)
+
+type MessageMention struct {
+	MessageID uuid.UUID `gorm:"type:uuid;primaryKey" json:"message_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	RoomID    uuid.UUID `gorm:"type:uuid;not null" json:"room_id"`
+	Kind      string    `gorm:"type:varchar(8);not null" json:"kind"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MentionedMessage is a message that mentions the user listing their
+// mentions, with the way they were mentioned.
+type MentionedMessage struct {
+	Message
+	MentionKind string `json:"mention_kind"`
+}
+
+// MentionPage lists mentions newest first. NextCursor goes further back.
+type MentionPage struct {
+	Mentions   []*MentionedMessage `json:"mentions"`
+	NextCursor string              `json:"next_cursor,omitempty"`
+}
+
+// NewMentionEvent builds the "mention" frame sent to a user mentioned in m.
+func NewMentionEvent(m *Message, kind string) *WebSocketMessage {
+	event := NewMessageEvent(m)
+	event.Type = "mention"
+	event.Data = map[string]any{"kind": kind}
+	return event
+}
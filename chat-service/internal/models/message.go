@@ -6,20 +6,151 @@ import (
 	"github.com/google/uuid"
 )
 
+const MaxClientMsgIDLength = 64
+
 type Message struct {
+	ID            uuid.UUID         `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RoomID        uuid.UUID         `gorm:"type:uuid;not null;index" json:"room_id"`
+	UserID        uuid.UUID         `gorm:"type:uuid;not null" json:"user_id"`
+	Username      string            `gorm:"not null" json:"username"`
+	Seq           int64             `gorm:"not null" json:"seq"`
+	Content       string            `gorm:"type:text;not null" json:"content"`
+	ParentID      *uuid.UUID        `gorm:"type:uuid" json:"parent_id,omitempty"`
+	ReplyCount    int               `gorm:"not null;default:0" json:"reply_count"`
+	LastReplyAt   *time.Time        `json:"last_reply_at,omitempty"`
+	ClientMsgID   *string           `gorm:"type:varchar(64)" json:"client_msg_id,omitempty"`
+	EditedAt      *time.Time        `json:"edited_at,omitempty"`
+	RevisionCount int               `gorm:"not null;default:0" json:"revision_count"`
+	DeletedAt     *time.Time        `json:"deleted_at,omitempty"`
+	DeletedBy     *uuid.UUID        `gorm:"type:uuid" json:"deleted_by,omitempty"`
+	CreatedAt     time.Time         `json:"created_at"`
+	Reactions     []ReactionSummary `gorm:"-" json:"reactions,omitempty"`
+	Mentions      []MessageMention  `gorm:"-" json:"-"`
+}
+
+// MessageRevision keeps the content a message had before an edit.
+type MessageRevision struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	RoomID    uuid.UUID `gorm:"type:uuid;not null;index" json:"room_id"`
-	UserID    uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
-	Username  string    `gorm:"not null" json:"username"`
+	MessageID uuid.UUID `gorm:"type:uuid;not null;index" json:"message_id"`
 	Content   string    `gorm:"type:text;not null" json:"content"`
+	EditedBy  uuid.UUID `gorm:"type:uuid;not null" json:"edited_by"`
 	CreatedAt time.Time `json:"created_at"`
 }
 
+type EditMessageRequest struct {
+	Content string `json:"content" validate:"required"`
+}
+
 type WebSocketMessage struct {
-	Type     string `json:"type"`
-	RoomID   string `json:"room_id,omitempty"`
-	UserID   string `json:"user_id,omitempty"`
-	Username string `json:"username,omitempty"`
-	Content  string `json:"content,omitempty"`
-	Data     any    `json:"data,omitempty"`
+	Type        string     `json:"type"`
+	ID          string     `json:"id,omitempty"`
+	ClientMsgID string     `json:"client_msg_id,omitempty"`
+	RoomID      string     `json:"room_id,omitempty"`
+	ParentID    string     `json:"parent_id,omitempty"`
+	Thread      bool       `json:"thread,omitempty"`
+	Seq         int64      `json:"seq,omitempty"`
+	LastSeq     int64      `json:"last_seq,omitempty"`
+	UserID      string     `json:"user_id,omitempty"`
+	Username    string     `json:"username,omitempty"`
+	Content     string     `json:"content,omitempty"`
+	Status      string     `json:"status,omitempty"`
+	Emoji       string     `json:"emoji,omitempty"`
+	AllDevices  bool       `json:"all_devices,omitempty"`
+	TargetID    string     `json:"target_id,omitempty"`
+	Reason      string     `json:"reason,omitempty"`
+	Duration    int        `json:"duration,omitempty"`
+	Timestamp   *time.Time `json:"timestamp,omitempty"`
+	Error       string     `json:"error,omitempty"`
+	Data        any        `json:"data,omitempty"`
+}
+
+// MessageQuery selects a page of room history. Before, After and Around take
+// a message ID or a sequence number; at most one of them is used. ParentID
+// narrows the page to one thread.
+type MessageQuery struct {
+	Before   string
+	After    string
+	Around   string
+	Limit    int
+	ParentID string
+}
+
+// MessagePage lists messages newest first. NextCursor goes further back in
+// history (pass it as before) and PrevCursor moves towards the present (pass
+// it as after); each is empty when there is nothing more in that direction.
+type MessagePage struct {
+	Messages   []*Message `json:"messages"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	PrevCursor string     `json:"prev_cursor,omitempty"`
+}
+
+// ThreadPage is a page of replies together with the message they answer.
+type ThreadPage struct {
+	Parent *Message `json:"parent"`
+	MessagePage
+}
+
+// NewMessageEvent builds the "message" frame clients receive for m.
+func NewMessageEvent(m *Message) *WebSocketMessage {
+	event := &WebSocketMessage{
+		Type:      "message",
+		ID:        m.ID.String(),
+		RoomID:    m.RoomID.String(),
+		Seq:       m.Seq,
+		UserID:    m.UserID.String(),
+		Username:  m.Username,
+		Content:   m.Content,
+		Timestamp: &m.CreatedAt,
+	}
+	if m.ClientMsgID != nil {
+		event.ClientMsgID = *m.ClientMsgID
+	}
+	if m.ParentID != nil {
+		event.ParentID = m.ParentID.String()
+		event.Thread = true
+	}
+	return event
+}
+
+// NewEditedEvent builds the "edited" frame sent after m's content changed.
+func NewEditedEvent(m *Message) *WebSocketMessage {
+	event := NewMessageEvent(m)
+	event.Type = "edited"
+	event.Data = map[string]any{
+		"edited_at":      m.EditedAt,
+		"revision_count": m.RevisionCount,
+	}
+	return event
+}
+
+// NewDeletedEvent builds the "message_deleted" tombstone for m.
+func NewDeletedEvent(m *Message) *WebSocketMessage {
+	return &WebSocketMessage{
+		Type:      "message_deleted",
+		ID:        m.ID.String(),
+		RoomID:    m.RoomID.String(),
+		Seq:       m.Seq,
+		UserID:    m.UserID.String(),
+		Username:  m.Username,
+		Timestamp: m.DeletedAt,
+		Data:      map[string]any{"deleted_by": m.DeletedBy},
+	}
+}
+
+// NewHistoryEvent replays m as a message or, once deleted, as its tombstone.
+func NewHistoryEvent(m *Message) *WebSocketMessage {
+	if m.DeletedAt != nil {
+		return NewDeletedEvent(m)
+	}
+	return NewMessageEvent(m)
+}
+
+// Envelope is what nodes exchange over the broker. NodeID identifies the
+// publishing node so it can skip its own messages on the way back. UserID is
+// set when the message is addressed to one user's connections rather than to
+// the members of Message.RoomID.
+type Envelope struct {
+	NodeID  string            `json:"node_id"`
+	UserID  string            `json:"user_id,omitempty"`
+	Message *WebSocketMessage `json:"message"`
 }
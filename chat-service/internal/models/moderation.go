@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	ModerationKick = "kick"
+	ModerationBan  = "ban"
+	ModerationMute = "mute"
+)
+
+const MaxMuteDuration = 30 * 24 * time.Hour
+
+type RoomBan struct {
+	RoomID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"room_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	BannedBy  uuid.UUID `gorm:"type:uuid;not null" json:"banned_by"`
+	Reason    string    `gorm:"type:text;not null;default:''" json:"reason"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ModerationLog records one moderator action. ExpiresAt is set for mutes.
+type ModerationLog struct {
+	ID        uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RoomID    uuid.UUID  `gorm:"type:uuid;not null;index" json:"room_id"`
+	ActorID   uuid.UUID  `gorm:"type:uuid;not null" json:"actor_id"`
+	TargetID  uuid.UUID  `gorm:"type:uuid;not null" json:"target_id"`
+	Action    string     `gorm:"type:varchar(16);not null" json:"action"`
+	Reason    string     `gorm:"type:text;not null;default:''" json:"reason"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// ModerationRequest is the body of the REST moderation endpoints. Duration is
+// the mute length in seconds and is ignored for kicks and bans.
+type ModerationRequest struct {
+	Reason   string `json:"reason"`
+	Duration int    `json:"duration"`
+}
+
+// NewModerationEvent builds the "moderation" frame for entry. UserID is the
+// user the action was taken against.
+func NewModerationEvent(entry *ModerationLog) *WebSocketMessage {
+	return &WebSocketMessage{
+		Type:      "moderation",
+		RoomID:    entry.RoomID.String(),
+		UserID:    entry.TargetID.String(),
+		Reason:    entry.Reason,
+		Timestamp: &entry.CreatedAt,
+		Data: map[string]any{
+			"action":     entry.Action,
+			"actor_id":   entry.ActorID,
+			"expires_at": entry.ExpiresAt,
+		},
+	}
+}
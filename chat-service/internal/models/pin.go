@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type RoomPin struct {
+	RoomID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"room_id"`
+	MessageID uuid.UUID `gorm:"type:uuid;primaryKey" json:"message_id"`
+	PinnedBy  uuid.UUID `gorm:"type:uuid;not null" json:"pinned_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// PinnedMessage is a pinned message together with who pinned it and when.
+type PinnedMessage struct {
+	Message
+	PinnedBy uuid.UUID `json:"pinned_by"`
+	PinnedAt time.Time `json:"pinned_at"`
+}
+
+// NewPinAddedEvent carries the pinned message so clients can show it without
+// fetching the pin list again.
+func NewPinAddedEvent(m *Message, pin *RoomPin) *WebSocketMessage {
+	event := NewMessageEvent(m)
+	event.Type = "pin_added"
+	event.Data = map[string]any{
+		"pinned_by": pin.PinnedBy,
+		"pinned_at": pin.CreatedAt,
+	}
+	return event
+}
+
+func NewPinRemovedEvent(roomID, messageID, userID string) *WebSocketMessage {
+	return &WebSocketMessage{
+		Type:   "pin_removed",
+		ID:     messageID,
+		RoomID: roomID,
+		Data:   map[string]any{"unpinned_by": userID},
+	}
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const MaxEmojiLength = 64
+
+type MessageReaction struct {
+	MessageID uuid.UUID `gorm:"type:uuid;primaryKey" json:"message_id"`
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"user_id"`
+	Emoji     string    `gorm:"type:varchar(64);primaryKey" json:"emoji"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ReactionSummary aggregates one emoji on one message. Reacted tells whether
+// the user asking for history is among those who reacted.
+type ReactionSummary struct {
+	MessageID uuid.UUID `json:"-"`
+	Emoji     string    `json:"emoji"`
+	Count     int       `json:"count"`
+	Reacted   bool      `json:"reacted"`
+}
@@ -6,23 +6,161 @@ import (
 	"github.com/google/uuid"
 )
 
+const (
+	RoomTypeGroup  = "group"
+	RoomTypeDirect = "direct"
+)
+
+// Roles are ordered: owners can do everything admins can, and admins
+// everything members can.
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Public rooms are listed and open to everyone, unlisted rooms are open to
+// anyone who knows their ID, and private rooms need an invitation.
+const (
+	VisibilityPublic   = "public"
+	VisibilityUnlisted = "unlisted"
+	VisibilityPrivate  = "private"
+)
+
 type Room struct {
-	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Name        string    `gorm:"not null" json:"name"`
-	Description string    `json:"description"`
-	CreatedBy   uuid.UUID `gorm:"type:uuid;not null" json:"created_by"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	Name        string     `gorm:"not null" json:"name"`
+	Description string     `json:"description"`
+	Type        string     `gorm:"type:varchar(16);not null;default:group" json:"type"`
+	Visibility  string     `gorm:"type:varchar(16);not null;default:public" json:"visibility"`
+	DMKey       *string    `gorm:"column:dm_key;type:varchar(80)" json:"-"`
+	CreatedBy   uuid.UUID  `gorm:"type:uuid;not null" json:"created_by"`
+	LastSeq     int64      `gorm:"not null;default:0" json:"last_seq"`
+	ArchivedAt  *time.Time `json:"archived_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+// NewRoomUpdatedEvent carries the room's new state after an edit or an
+// archive change.
+func NewRoomUpdatedEvent(room *Room) *WebSocketMessage {
+	return &WebSocketMessage{
+		Type:   "room_updated",
+		RoomID: room.ID.String(),
+		Data:   room,
+	}
+}
+
+// NewRoomDeletedEvent tells members the room is gone; nodes drop their local
+// members from it when they see this event.
+func NewRoomDeletedEvent(roomID string) *WebSocketMessage {
+	return &WebSocketMessage{
+		Type:   "room_deleted",
+		RoomID: roomID,
+	}
 }
 
 type RoomParticipant struct {
-	ID       uuid.UUID `gorm:"uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	RoomID   uuid.UUID `gorm:"type:uuid;not null;index" json:"room_id"`
-	UserID   uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
-	JoinedAt time.Time `json:"joined_at"`
+	ID         uuid.UUID  `gorm:"uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	RoomID     uuid.UUID  `gorm:"type:uuid;not null;index" json:"room_id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;not null" json:"user_id"`
+	Username   string     `gorm:"type:varchar(50);not null;default:''" json:"username,omitempty"`
+	Role       string     `gorm:"type:varchar(16);not null;default:member" json:"role"`
+	MutedUntil *time.Time `json:"muted_until,omitempty"`
+	JoinedAt   time.Time  `gorm:"autoCreateTime" json:"joined_at"`
+
+	// The read position only moves forward.
+	LastReadSeq       int64      `gorm:"not null;default:0" json:"last_read_seq"`
+	LastReadMessageID *uuid.UUID `gorm:"type:uuid" json:"last_read_message_id,omitempty"`
+	LastReadAt        *time.Time `json:"last_read_at,omitempty"`
+}
+
+// UnreadCount is the number of messages in a room the user has not read,
+// not counting their own or deleted ones.
+type UnreadCount struct {
+	RoomID      uuid.UUID `json:"room_id"`
+	LastReadSeq int64     `json:"last_read_seq"`
+	Unread      int64     `json:"unread"`
+}
+
+// NewReadReceiptEvent tells the room how far p has read.
+func NewReadReceiptEvent(p *RoomParticipant, username string) *WebSocketMessage {
+	event := &WebSocketMessage{
+		Type:      "read_receipt",
+		RoomID:    p.RoomID.String(),
+		Seq:       p.LastReadSeq,
+		UserID:    p.UserID.String(),
+		Username:  username,
+		Timestamp: p.LastReadAt,
+	}
+	if p.LastReadMessageID != nil {
+		event.ID = p.LastReadMessageID.String()
+	}
+	return event
+}
+
+// NewRoleChangedEvent tells the room that p's role changed.
+func NewRoleChangedEvent(p *RoomParticipant) *WebSocketMessage {
+	return &WebSocketMessage{
+		Type:   "role_changed",
+		RoomID: p.RoomID.String(),
+		UserID: p.UserID.String(),
+		Data:   map[string]any{"role": p.Role},
+	}
 }
 
 type CreateRoomRequest struct {
 	Name        string `json:"name" validate:"required,min=3,max=100"`
 	Description string `json:"description"`
+	Visibility  string `json:"visibility" validate:"omitempty,oneof=public unlisted private"`
+}
+
+// MaxPreviewLength caps the last-message preview in room listings, in
+// characters.
+const MaxPreviewLength = 200
+
+// RoomSummary is a room as it appears in listings. The last-message fields
+// are empty for rooms nobody has written in yet.
+type RoomSummary struct {
+	Room
+	MemberCount         int        `json:"member_count"`
+	LastMessageID       *uuid.UUID `json:"last_message_id,omitempty"`
+	LastMessageUsername *string    `json:"last_message_username,omitempty"`
+	LastMessageContent  *string    `json:"last_message_preview,omitempty"`
+	LastMessageAt       *time.Time `json:"last_message_at,omitempty"`
+}
+
+// RoomQuery filters a room listing. Without Mine only public rooms are
+// listed; with it, every room the caller participates in. Cursor is the ID of
+// the last room of the previous page.
+type RoomQuery struct {
+	Search string
+	Mine   bool
+	Cursor string
+	Limit  int
+}
+
+// RoomPage lists rooms newest first; NextCursor is empty on the last page.
+type RoomPage struct {
+	Rooms      []*RoomSummary `json:"rooms"`
+	NextCursor string         `json:"next_cursor,omitempty"`
+}
+
+// UpdateRoomRequest changes only the fields that are present.
+type UpdateRoomRequest struct {
+	Name        *string `json:"name" validate:"omitempty,min=3,max=100"`
+	Description *string `json:"description"`
+}
+
+type TransferOwnershipRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+type CreateDirectMessageRequest struct {
+	UserID string `json:"user_id" validate:"required,uuid"`
+}
+
+type UserPresence struct {
+	UserID string `json:"user_id"`
+	Status string `json:"status"`
 }
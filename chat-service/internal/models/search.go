@@ -0,0 +1,33 @@
+package models
+
+import "time"
+
+const MaxSearchQueryLength = 200
+
+// SearchQuery narrows a message search. Only Text is required; From matches
+// the author's user ID or username, and Before and After bound created_at.
+type SearchQuery struct {
+	Text   string
+	RoomID string
+	From   string
+	Before *time.Time
+	After  *time.Time
+	Limit  int
+	Offset int
+}
+
+// SearchResult is a matching message with its rank and a snippet in which
+// the matched words are wrapped in <mark> tags; the rest of the snippet is
+// HTML-escaped.
+type SearchResult struct {
+	Message
+	Rank    float64 `json:"rank"`
+	Snippet string  `json:"snippet"`
+}
+
+// SearchPage lists results best match first. NextOffset is set when there
+// are more results and is passed back as offset.
+type SearchPage struct {
+	Results    []*SearchResult `json:"results"`
+	NextOffset int             `json:"next_offset,omitempty"`
+}
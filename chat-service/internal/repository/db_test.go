@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openTestDB connects to the database named by CHAT_TEST_DATABASE_URL, a
+// postgres:// URL, and brings it up to the latest migration. Tests that need
+// a database are skipped when the variable is unset.
+func openTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+
+	dsn := os.Getenv("CHAT_TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("CHAT_TEST_DATABASE_URL is not set")
+	}
+
+	m, err := migrate.New("file://../database/migrations", dsn)
+	if err != nil {
+		t.Fatalf("create migrate instance: %v", err)
+	}
+	defer m.Close()
+
+	if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+		t.Fatalf("run migrations: %v", err)
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		t.Fatalf("connect to database: %v", err)
+	}
+	return db
+}
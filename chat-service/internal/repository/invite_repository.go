@@ -0,0 +1,106 @@
+package repository
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type InviteRepository interface {
+	CreateInvitation(invitation *models.RoomInvitation) error
+	AcceptInvitation(roomID, userID uuid.UUID, username string) (bool, error)
+	CreateLink(link *models.RoomInviteLink) error
+	RedeemLink(token string, userID uuid.UUID, username string) (uuid.UUID, error)
+}
+
+// errAlreadyParticipant rolls back the use counted by RedeemLink when the
+// user was already in the room.
+var errAlreadyParticipant = errors.New("already a participant")
+
+type inviteRepository struct {
+	db *gorm.DB
+}
+
+func NewInviteRepository(db *gorm.DB) InviteRepository {
+	return &inviteRepository{db: db}
+}
+
+// CreateInvitation is a no-op when the user is already invited.
+func (r *inviteRepository) CreateInvitation(invitation *models.RoomInvitation) error {
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(invitation).Error
+}
+
+// AcceptInvitation consumes the user's invitation and makes them a
+// participant in one transaction. It reports false when there was no
+// invitation.
+func (r *inviteRepository) AcceptInvitation(roomID, userID uuid.UUID, username string) (bool, error) {
+	accepted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("room_id = ? AND user_id = ?", roomID, userID).Delete(&models.RoomInvitation{})
+		if result.Error != nil || result.RowsAffected == 0 {
+			return result.Error
+		}
+
+		accepted = true
+		participant := &models.RoomParticipant{RoomID: roomID, UserID: userID, Username: username, Role: models.RoleMember}
+		_, err := insertParticipant(tx, participant)
+		return err
+	})
+	return accepted, err
+}
+
+func (r *inviteRepository) CreateLink(link *models.RoomInviteLink) error {
+	return r.db.Create(link).Error
+}
+
+// RedeemLink counts one use of the link and adds the user to its room. The
+// expiry and use limit are checked by the same UPDATE that bumps the counter,
+// so concurrent redemptions cannot go over max_uses; users who were already
+// in the room do not use the link up. An unknown, expired or used-up token,
+// or a user banned from the room, yields gorm.ErrRecordNotFound.
+func (r *inviteRepository) RedeemLink(token string, userID uuid.UUID, username string) (uuid.UUID, error) {
+	var roomID uuid.UUID
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Raw(`UPDATE room_invite_links SET uses = uses + 1
+			WHERE token = ? AND expires_at > ? AND (max_uses = 0 OR uses < max_uses)
+			RETURNING room_id`, token, time.Now()).
+			Scan(&roomID).Error
+		if err != nil {
+			return err
+		}
+		if roomID == uuid.Nil {
+			return gorm.ErrRecordNotFound
+		}
+
+		// A banned user cannot use a link to get back in.
+		var banned int64
+		err = tx.Model(&models.RoomBan{}).
+			Where("room_id = ? AND user_id = ?", roomID, userID).
+			Count(&banned).Error
+		if err != nil {
+			return err
+		}
+		if banned > 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		participant := &models.RoomParticipant{RoomID: roomID, UserID: userID, Username: username, Role: models.RoleMember}
+		inserted, err := insertParticipant(tx, participant)
+		if err != nil {
+			return err
+		}
+		if !inserted {
+			return errAlreadyParticipant
+		}
+		return nil
+	})
+
+	if errors.Is(err, errAlreadyParticipant) {
+		return roomID, nil
+	}
+	return roomID, err
+}
@@ -1,15 +1,42 @@
 package repository
 
 import (
+	"errors"
+	"time"
+
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type MessageRepository interface {
-	Create(message *models.Message) error
-	FindByRoomID(roomID string, limit int) ([]*models.Message, error)
+	Create(message *models.Message) (bool, error)
+	FindByClientMsgID(userID, clientMsgID string) (*models.Message, error)
+	FindByID(id string) (*models.Message, error)
+	FindBySeq(roomID string, seq int64) (*models.Message, error)
+	FindBefore(roomID, parentID, cursorID string, limit int) ([]*models.Message, error)
+	FindAfter(roomID, parentID, cursorID string, limit int) ([]*models.Message, error)
+	FindAfterSeq(roomID string, afterSeq int64, limit int) ([]*models.Message, error)
+	FindRange(roomID string, afterSeq int64, from, to *time.Time, limit int) ([]*models.Message, error)
+	UpdateContent(id string, editedBy uuid.UUID, content string) (*models.Message, error)
+	SoftDelete(id string, deletedBy uuid.UUID) (*models.Message, bool, error)
+	PurgeDeleted(before time.Time) (int64, error)
+	FindMentions(userID, cursorID string, limit int) ([]*models.MentionedMessage, error)
+	Search(userID string, query *models.SearchQuery, limit int) ([]*models.SearchResult, error)
 }
 
+// Search snippets mark matches with these control characters so the service
+// can escape the snippet before turning them into tags.
+const (
+	SearchMatchStart = "\x02"
+	SearchMatchStop  = "\x03"
+)
+
+// errDuplicateMessage rolls back the sequence bump when the insert turns out
+// to be a retry.
+var errDuplicateMessage = errors.New("duplicate message")
+
 type messageRepository struct {
 	db *gorm.DB
 }
@@ -18,15 +45,286 @@ func NewMessageRepository(db *gorm.DB) MessageRepository {
 	return &messageRepository{db: db}
 }
 
-func (r *messageRepository) Create(message *models.Message) error {
-	return r.db.Create(message).Error
+// Create assigns the next sequence number of the message's room and inserts
+// it in the same transaction, so sequence numbers have no gaps. Replies also
+// bump their parent's reply count, and message.Mentions are stored with the
+// message. It reports
+// false when a message with the same (user_id, client_msg_id) already exists,
+// in which case nothing is written. A missing room yields
+// gorm.ErrRecordNotFound.
+func (r *messageRepository) Create(message *models.Message) (bool, error) {
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var seq int64
+		err := tx.Raw("UPDATE rooms SET last_seq = last_seq + 1 WHERE id = ? RETURNING last_seq", message.RoomID).
+			Scan(&seq).Error
+		if err != nil {
+			return err
+		}
+		if seq == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		message.Seq = seq
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(message)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return errDuplicateMessage
+		}
+
+		if len(message.Mentions) > 0 {
+			for i := range message.Mentions {
+				message.Mentions[i].MessageID = message.ID
+				message.Mentions[i].RoomID = message.RoomID
+				message.Mentions[i].CreatedAt = message.CreatedAt
+			}
+			if err := tx.Create(&message.Mentions).Error; err != nil {
+				return err
+			}
+		}
+
+		if message.ParentID == nil {
+			return nil
+		}
+		return tx.Model(&models.Message{}).
+			Where("id = ?", *message.ParentID).
+			Updates(map[string]any{
+				"reply_count":   gorm.Expr("reply_count + 1"),
+				"last_reply_at": message.CreatedAt,
+			}).Error
+	})
+
+	if errors.Is(err, errDuplicateMessage) {
+		message.Seq = 0
+		message.Mentions = nil
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *messageRepository) FindByClientMsgID(userID, clientMsgID string) (*models.Message, error) {
+	var message models.Message
+	err := r.db.Where("user_id = ? AND client_msg_id = ?", userID, clientMsgID).First(&message).Error
+	return &message, err
+}
+
+func (r *messageRepository) FindByID(id string) (*models.Message, error) {
+	var message models.Message
+	err := r.db.Where("id = ?", id).First(&message).Error
+	return &message, err
+}
+
+func (r *messageRepository) FindBySeq(roomID string, seq int64) (*models.Message, error) {
+	var message models.Message
+	err := r.db.Where("room_id = ? AND seq = ?", roomID, seq).First(&message).Error
+	return &message, err
+}
+
+// FindBefore returns up to limit messages older than cursorID, newest first.
+// An empty cursorID starts from the newest message in the room. A non-empty
+// parentID restricts the result to that message's thread.
+func (r *messageRepository) FindBefore(roomID, parentID, cursorID string, limit int) ([]*models.Message, error) {
+	var messages []*models.Message
+	query := r.scope(roomID, parentID)
+	if cursorID != "" {
+		query = query.Where("(created_at, id) < (SELECT created_at, id FROM messages WHERE id = ?)", cursorID)
+	}
+	err := query.Order("created_at DESC, id DESC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+// FindAfter returns up to limit messages newer than cursorID, oldest first.
+func (r *messageRepository) FindAfter(roomID, parentID, cursorID string, limit int) ([]*models.Message, error) {
+	var messages []*models.Message
+	err := r.scope(roomID, parentID).
+		Where("(created_at, id) > (SELECT created_at, id FROM messages WHERE id = ?)", cursorID).
+		Order("created_at ASC, id ASC").
+		Limit(limit).
+		Find(&messages).Error
+	return messages, err
+}
+
+func (r *messageRepository) scope(roomID, parentID string) *gorm.DB {
+	query := r.db.Where("room_id = ?", roomID)
+	if parentID != "" {
+		query = query.Where("parent_id = ?", parentID)
+	}
+	return query
 }
 
-func (r *messageRepository) FindByRoomID(roomID string, limit int) ([]*models.Message, error) {
+func (r *messageRepository) FindAfterSeq(roomID string, afterSeq int64, limit int) ([]*models.Message, error) {
 	var messages []*models.Message
-	err := r.db.Where("room_id = ?", roomID).
-		Order("created_at DESC").
+	err := r.db.Where("room_id = ? AND seq > ?", roomID, afterSeq).
+		Order("seq ASC").
 		Limit(limit).
 		Find(&messages).Error
 	return messages, err
 }
+
+// FindRange pages through a room oldest first, including deleted messages,
+// optionally bounded by created_at (from inclusive, to exclusive).
+func (r *messageRepository) FindRange(roomID string, afterSeq int64, from, to *time.Time, limit int) ([]*models.Message, error) {
+	query := r.db.Where("room_id = ? AND seq > ?", roomID, afterSeq)
+	if from != nil {
+		query = query.Where("created_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("created_at < ?", *to)
+	}
+
+	var messages []*models.Message
+	err := query.Order("seq ASC").Limit(limit).Find(&messages).Error
+	return messages, err
+}
+
+// UpdateContent replaces the message's content and records the previous
+// version as a revision. The row is locked first so concurrent edits each
+// keep the version they replaced.
+func (r *messageRepository) UpdateContent(id string, editedBy uuid.UUID, content string) (*models.Message, error) {
+	var message models.Message
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&message).Error; err != nil {
+			return err
+		}
+
+		revision := &models.MessageRevision{
+			MessageID: message.ID,
+			Content:   message.Content,
+			EditedBy:  editedBy,
+		}
+		if err := tx.Create(revision).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		message.Content = content
+		message.EditedAt = &now
+		message.RevisionCount++
+
+		return tx.Model(&message).Updates(map[string]any{
+			"content":        message.Content,
+			"edited_at":      message.EditedAt,
+			"revision_count": message.RevisionCount,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &message, nil
+}
+
+// SoftDelete turns the message into a tombstone: the row and its sequence
+// number stay, the content is blanked. Deleting a tombstone is a no-op that
+// returns it unchanged with deleted set to false.
+func (r *messageRepository) SoftDelete(id string, deletedBy uuid.UUID) (*models.Message, bool, error) {
+	var message models.Message
+	deleted := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", id).First(&message).Error; err != nil {
+			return err
+		}
+		if message.DeletedAt != nil {
+			return nil
+		}
+		deleted = true
+
+		now := time.Now()
+		message.Content = ""
+		message.DeletedAt = &now
+		message.DeletedBy = &deletedBy
+
+		return tx.Model(&message).Updates(map[string]any{
+			"content":    message.Content,
+			"deleted_at": message.DeletedAt,
+			"deleted_by": message.DeletedBy,
+		}).Error
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return &message, deleted, nil
+}
+
+// PurgeDeleted drops the revisions of tombstones deleted before the cutoff
+// and makes sure their content is gone. The rows themselves stay, so replies
+// keep their parent and room sequence numbers have no holes. It returns how
+// many tombstones still held something to purge.
+func (r *messageRepository) PurgeDeleted(before time.Time) (int64, error) {
+	var purged int64
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("message_id IN (SELECT id FROM messages WHERE deleted_at IS NOT NULL AND deleted_at < ?)", before).
+			Delete(&models.MessageRevision{}).Error
+		if err != nil {
+			return err
+		}
+
+		result := tx.Model(&models.Message{}).
+			Where("deleted_at IS NOT NULL AND deleted_at < ?", before).
+			Where("content <> '' OR revision_count > 0").
+			Updates(map[string]any{"content": "", "revision_count": 0})
+		purged = result.RowsAffected
+		return result.Error
+	})
+	return purged, err
+}
+
+// FindMentions returns messages mentioning the user, newest first and older
+// than cursorID when it is set. Deleted messages and rooms the user has since
+// left are skipped.
+func (r *messageRepository) FindMentions(userID, cursorID string, limit int) ([]*models.MentionedMessage, error) {
+	query := r.db.Table("message_mentions mm").
+		Select("messages.*, mm.kind AS mention_kind").
+		Joins("JOIN messages ON messages.id = mm.message_id").
+		Where("mm.user_id = ? AND messages.deleted_at IS NULL", userID).
+		Where("EXISTS (SELECT 1 FROM room_participants rp WHERE rp.room_id = mm.room_id AND rp.user_id = mm.user_id)")
+	if cursorID != "" {
+		query = query.Where("(mm.created_at, mm.message_id) < (SELECT created_at, message_id FROM message_mentions WHERE user_id = ? AND message_id = ?)",
+			userID, cursorID)
+	}
+
+	var mentions []*models.MentionedMessage
+	err := query.Order("mm.created_at DESC, mm.message_id DESC").
+		Limit(limit).
+		Scan(&mentions).Error
+	return mentions, err
+}
+
+// Search runs a full-text query over the messages of rooms userID
+// participates in, best match first.
+func (r *messageRepository) Search(userID string, query *models.SearchQuery, limit int) ([]*models.SearchResult, error) {
+	headlineOptions := "StartSel=" + SearchMatchStart + ", StopSel=" + SearchMatchStop +
+		", MaxWords=30, MinWords=10, MaxFragments=2"
+
+	db := r.db.Table("messages, websearch_to_tsquery('english', ?) AS q", query.Text).
+		Select("messages.*, ts_rank(messages.search_vector, q) AS rank, ts_headline('english', messages.content, q, ?) AS snippet",
+			headlineOptions).
+		Where("messages.search_vector @@ q AND messages.deleted_at IS NULL").
+		Where("EXISTS (SELECT 1 FROM room_participants rp WHERE rp.room_id = messages.room_id AND rp.user_id = ?)", userID)
+
+	if query.RoomID != "" {
+		db = db.Where("messages.room_id = ?", query.RoomID)
+	}
+	if query.From != "" {
+		if _, err := uuid.Parse(query.From); err == nil {
+			db = db.Where("messages.user_id = ?", query.From)
+		} else {
+			db = db.Where("messages.username = ?", query.From)
+		}
+	}
+	if query.Before != nil {
+		db = db.Where("messages.created_at < ?", *query.Before)
+	}
+	if query.After != nil {
+		db = db.Where("messages.created_at > ?", *query.After)
+	}
+
+	var results []*models.SearchResult
+	err := db.Order("rank DESC, messages.created_at DESC, messages.id DESC").
+		Offset(query.Offset).
+		Limit(limit).
+		Scan(&results).Error
+	return results, err
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ModerationRepository applies moderator actions. Each action and its
+// moderation log entry are written in one transaction.
+type ModerationRepository interface {
+	Kick(entry *models.ModerationLog) error
+	Ban(entry *models.ModerationLog) error
+	Mute(entry *models.ModerationLog) error
+	IsBanned(roomID, userID string) (bool, error)
+}
+
+type moderationRepository struct {
+	db *gorm.DB
+}
+
+func NewModerationRepository(db *gorm.DB) ModerationRepository {
+	return &moderationRepository{db: db}
+}
+
+// Kick removes the target's participant row.
+func (r *moderationRepository) Kick(entry *models.ModerationLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := deleteParticipant(tx, entry); err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+// Ban records the ban and removes the target's participant row and any
+// pending invitation, so neither can be used to get back in.
+func (r *moderationRepository) Ban(entry *models.ModerationLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		ban := &models.RoomBan{
+			RoomID:   entry.RoomID,
+			UserID:   entry.TargetID,
+			BannedBy: entry.ActorID,
+			Reason:   entry.Reason,
+		}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(ban).Error; err != nil {
+			return err
+		}
+
+		if err := deleteParticipant(tx, entry); err != nil {
+			return err
+		}
+
+		err := tx.Where("room_id = ? AND user_id = ?", entry.RoomID, entry.TargetID).
+			Delete(&models.RoomInvitation{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Create(entry).Error
+	})
+}
+
+// Mute silences the target until entry.ExpiresAt.
+func (r *moderationRepository) Mute(entry *models.ModerationLog) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.RoomParticipant{}).
+			Where("room_id = ? AND user_id = ?", entry.RoomID, entry.TargetID).
+			Update("muted_until", entry.ExpiresAt).Error
+		if err != nil {
+			return err
+		}
+		return tx.Create(entry).Error
+	})
+}
+
+func (r *moderationRepository) IsBanned(roomID, userID string) (bool, error) {
+	var count int64
+	err := r.db.Model(&models.RoomBan{}).
+		Where("room_id = ? AND user_id = ?", roomID, userID).
+		Count(&count).Error
+	return count > 0, err
+}
+
+func deleteParticipant(tx *gorm.DB, entry *models.ModerationLog) error {
+	return tx.Where("room_id = ? AND user_id = ?", entry.RoomID, entry.TargetID).
+		Delete(&models.RoomParticipant{}).Error
+}
@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReactionRepository interface {
+	Add(reaction *models.MessageReaction) (bool, error)
+	Remove(messageID, userID, emoji string) (bool, error)
+	Summaries(messageIDs []uuid.UUID, userID string) (map[uuid.UUID][]models.ReactionSummary, error)
+}
+
+type reactionRepository struct {
+	db *gorm.DB
+}
+
+func NewReactionRepository(db *gorm.DB) ReactionRepository {
+	return &reactionRepository{db: db}
+}
+
+// Add reports false when the user had already reacted with that emoji.
+func (r *reactionRepository) Add(reaction *models.MessageReaction) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(reaction)
+	return result.RowsAffected > 0, result.Error
+}
+
+// Remove reports false when there was no such reaction.
+func (r *reactionRepository) Remove(messageID, userID, emoji string) (bool, error) {
+	result := r.db.Where("message_id = ? AND user_id = ? AND emoji = ?", messageID, userID, emoji).
+		Delete(&models.MessageReaction{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// Summaries aggregates reactions for a whole page of messages in one query.
+func (r *reactionRepository) Summaries(messageIDs []uuid.UUID, userID string) (map[uuid.UUID][]models.ReactionSummary, error) {
+	summaries := make(map[uuid.UUID][]models.ReactionSummary)
+	if len(messageIDs) == 0 {
+		return summaries, nil
+	}
+
+	var rows []models.ReactionSummary
+	err := r.db.Model(&models.MessageReaction{}).
+		Select("message_id, emoji, COUNT(*) AS count, BOOL_OR(user_id = ?) AS reacted", userID).
+		Where("message_id IN ?", messageIDs).
+		Group("message_id, emoji").
+		Order("MIN(created_at)").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+
+	for _, row := range rows {
+		summaries[row.MessageID] = append(summaries[row.MessageID], row)
+	}
+	return summaries, nil
+}
@@ -1,18 +1,43 @@
 package repository
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type RoomRepository interface {
 	Create(room *models.Room) error
+	CreateDirect(room *models.Room, participants []*models.RoomParticipant) (*models.Room, bool, error)
 	FindByID(id string) (*models.Room, error)
-	ListAll() ([]*models.Room, error)
+	Update(id string, updates map[string]any) (*models.Room, error)
+	Delete(id string) error
+	TransferOwnership(roomID string, fromUserID, toUserID uuid.UUID) error
+	List(query *models.RoomQuery, userID string, limit int) ([]*models.RoomSummary, error)
 	AddParticipant(participant *models.RoomParticipant) error
 	IsParticipant(roomID, userID string) (bool, error)
+	FindParticipant(roomID, userID string) (*models.RoomParticipant, error)
+	UpdateParticipantRole(roomID, userID, role string) error
+	UpdateParticipantUsername(roomID, userID, username string) error
+	MarkRead(participant *models.RoomParticipant) (bool, error)
+	UnreadCounts(userID string) ([]models.UnreadCount, error)
+	ListParticipantIDs(roomID string) ([]string, error)
+	FindParticipantIDsByUsername(roomID string, usernames []string) ([]uuid.UUID, error)
+	ListRoomIDsByUser(userID string) ([]string, error)
+	AddPin(pin *models.RoomPin, limit int) (bool, error)
+	RemovePin(roomID, messageID string) (bool, error)
+	ListPins(roomID string) ([]*models.PinnedMessage, error)
 }
 
+// ErrPinLimitReached is returned by AddPin when the room already has as many
+// pins as it may.
+var ErrPinLimitReached = errors.New("pin limit reached")
+
 type roomRepository struct {
 	db *gorm.DB
 }
@@ -25,20 +50,164 @@ func (r *roomRepository) Create(room *models.Room) error {
 	return r.db.Create(room).Error
 }
 
+// CreateDirect creates a direct room together with its participants. If the
+// pair already has one, possibly created by a concurrent request, that room
+// is returned with created set to false.
+func (r *roomRepository) CreateDirect(room *models.Room, participants []*models.RoomParticipant) (*models.Room, bool, error) {
+	created := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(room)
+		if result.Error != nil {
+			return result.Error
+		}
+
+		if result.RowsAffected == 0 {
+			return tx.Where("dm_key = ?", *room.DMKey).First(room).Error
+		}
+
+		created = true
+		for _, participant := range participants {
+			participant.RoomID = room.ID
+			if _, err := insertParticipant(tx, participant); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return room, created, nil
+}
+
 func (r *roomRepository) FindByID(id string) (*models.Room, error) {
 	var room models.Room
 	err := r.db.Where("id = ?", id).First(&room).Error
 	return &room, err
 }
 
-func (r *roomRepository) ListAll() ([]*models.Room, error) {
-	var rooms []*models.Room
-	err := r.db.Order("created_at DESC").Find(&rooms).Error
+// Update writes the given columns and returns the room as stored, with
+// updated_at bumped.
+func (r *roomRepository) Update(id string, updates map[string]any) (*models.Room, error) {
+	var room models.Room
+	result := r.db.Model(&room).
+		Clauses(clause.Returning{}).
+		Where("id = ?", id).
+		Updates(updates)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &room, nil
+}
+
+// Delete removes the room; its participants, messages and everything hanging
+// off them go with it through ON DELETE CASCADE.
+func (r *roomRepository) Delete(id string) error {
+	result := r.db.Where("id = ?", id).Delete(&models.Room{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// TransferOwnership makes toUserID the owner and fromUserID an admin in one
+// transaction. It yields gorm.ErrRecordNotFound, changing nothing, when
+// fromUserID is no longer the owner or toUserID is not a participant.
+func (r *roomRepository) TransferOwnership(roomID string, fromUserID, toUserID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.RoomParticipant{}).
+			Where("room_id = ? AND user_id = ? AND role = ?", roomID, fromUserID, models.RoleOwner).
+			Update("role", models.RoleAdmin)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		result = tx.Model(&models.RoomParticipant{}).
+			Where("room_id = ? AND user_id = ?", roomID, toUserID).
+			Update("role", models.RoleOwner)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// List returns one page of rooms with their member counts and latest
+// top-level message, all in a single query.
+func (r *roomRepository) List(query *models.RoomQuery, userID string, limit int) ([]*models.RoomSummary, error) {
+	db := r.db.Table("rooms").
+		Select(`rooms.*,
+			(SELECT COUNT(*) FROM room_participants rp WHERE rp.room_id = rooms.id) AS member_count,
+			lm.id AS last_message_id,
+			lm.username AS last_message_username,
+			LEFT(lm.content, ?) AS last_message_content,
+			lm.created_at AS last_message_at`, models.MaxPreviewLength).
+		Joins(`LEFT JOIN LATERAL (
+			SELECT id, username, content, created_at FROM messages
+			WHERE messages.room_id = rooms.id AND messages.parent_id IS NULL AND messages.deleted_at IS NULL
+			ORDER BY created_at DESC, id DESC
+			LIMIT 1
+		) lm ON true`)
+
+	if query.Mine {
+		db = db.Where("EXISTS (SELECT 1 FROM room_participants rp WHERE rp.room_id = rooms.id AND rp.user_id = ?)", userID)
+	} else {
+		db = db.Where("rooms.visibility = ?", models.VisibilityPublic)
+	}
+
+	if query.Search != "" {
+		pattern := "%" + likeEscaper.Replace(query.Search) + "%"
+		db = db.Where("(rooms.name ILIKE ? OR rooms.description ILIKE ?)", pattern, pattern)
+	}
+
+	if query.Cursor != "" {
+		db = db.Where("(rooms.created_at, rooms.id) < (SELECT created_at, id FROM rooms WHERE id = ?)", query.Cursor)
+	}
+
+	var rooms []*models.RoomSummary
+	err := db.Order("rooms.created_at DESC, rooms.id DESC").
+		Limit(limit).
+		Scan(&rooms).Error
 	return rooms, err
 }
 
+// likeEscaper makes user input match literally inside a LIKE pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// AddParticipant is a no-op when the user is already in the room.
 func (r *roomRepository) AddParticipant(participant *models.RoomParticipant) error {
-	return r.db.Create(participant).Error
+	_, err := insertParticipant(r.db, participant)
+	return err
+}
+
+// insertParticipant adds participant unless they are already in the room and
+// reports whether a row was written. The read position starts at the room's
+// latest message, so history from before the user joined is never unread.
+// Every path that adds participants goes through here.
+func insertParticipant(tx *gorm.DB, participant *models.RoomParticipant) (bool, error) {
+	role := participant.Role
+	if role == "" {
+		role = models.RoleMember
+	}
+
+	result := tx.Raw(`INSERT INTO room_participants (room_id, user_id, username, role, joined_at, last_read_seq)
+		SELECT rooms.id, ?, ?, ?, ?, rooms.last_seq FROM rooms WHERE rooms.id = ?
+		ON CONFLICT (room_id, user_id) DO NOTHING
+		RETURNING *`, participant.UserID, participant.Username, role, time.Now(), participant.RoomID).
+		Scan(participant)
+	return result.RowsAffected > 0, result.Error
 }
 
 func (r *roomRepository) IsParticipant(roomID, userID string) (bool, error) {
@@ -48,3 +217,148 @@ func (r *roomRepository) IsParticipant(roomID, userID string) (bool, error) {
 		Count(&count).Error
 	return count > 0, err
 }
+
+func (r *roomRepository) FindParticipant(roomID, userID string) (*models.RoomParticipant, error) {
+	var participant models.RoomParticipant
+	err := r.db.Where("room_id = ? AND user_id = ?", roomID, userID).First(&participant).Error
+	return &participant, err
+}
+
+func (r *roomRepository) UpdateParticipantRole(roomID, userID, role string) error {
+	return r.db.Model(&models.RoomParticipant{}).
+		Where("room_id = ? AND user_id = ?", roomID, userID).
+		Update("role", role).Error
+}
+
+func (r *roomRepository) UpdateParticipantUsername(roomID, userID, username string) error {
+	return r.db.Model(&models.RoomParticipant{}).
+		Where("room_id = ? AND user_id = ?", roomID, userID).
+		Update("username", username).Error
+}
+
+// MarkRead stores participant's read position unless it is behind the one
+// already stored, and reports whether it moved.
+func (r *roomRepository) MarkRead(participant *models.RoomParticipant) (bool, error) {
+	result := r.db.Model(&models.RoomParticipant{}).
+		Where("room_id = ? AND user_id = ? AND last_read_seq < ?",
+			participant.RoomID, participant.UserID, participant.LastReadSeq).
+		Updates(map[string]any{
+			"last_read_seq":        participant.LastReadSeq,
+			"last_read_message_id": participant.LastReadMessageID,
+			"last_read_at":         participant.LastReadAt,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// UnreadCounts counts, for every room the user participates in, the messages
+// after their read position. Messages from before they joined do not count
+// because the read position starts at the room's last message.
+func (r *roomRepository) UnreadCounts(userID string) ([]models.UnreadCount, error) {
+	var counts []models.UnreadCount
+	err := r.db.Table("room_participants rp").
+		Select("rp.room_id, rp.last_read_seq, COUNT(m.id) AS unread").
+		Joins(`LEFT JOIN messages m ON m.room_id = rp.room_id
+			AND m.seq > rp.last_read_seq
+			AND m.user_id <> rp.user_id
+			AND m.deleted_at IS NULL`).
+		Where("rp.user_id = ?", userID).
+		Group("rp.room_id, rp.last_read_seq").
+		Scan(&counts).Error
+	return counts, err
+}
+
+func (r *roomRepository) ListParticipantIDs(roomID string) ([]string, error) {
+	var userIDs []string
+	err := r.db.Model(&models.RoomParticipant{}).
+		Where("room_id = ?", roomID).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+// FindParticipantIDsByUsername resolves usernames among the room's
+// participants, using the username recorded when they joined.
+func (r *roomRepository) FindParticipantIDsByUsername(roomID string, usernames []string) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	if len(usernames) == 0 {
+		return userIDs, nil
+	}
+
+	err := r.db.Model(&models.RoomParticipant{}).
+		Where("room_id = ? AND username IN ?", roomID, usernames).
+		Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
+
+func (r *roomRepository) ListRoomIDsByUser(userID string) ([]string, error) {
+	var roomIDs []string
+	err := r.db.Model(&models.RoomParticipant{}).
+		Where("user_id = ?", userID).
+		Pluck("room_id", &roomIDs).Error
+	return roomIDs, err
+}
+
+// AddPin pins a message unless the room already has limit pins. The room row
+// is locked while counting so concurrent pins cannot go over the limit. It
+// reports false when the message was already pinned, in which case pin is
+// filled with the stored row.
+func (r *roomRepository) AddPin(pin *models.RoomPin, limit int) (bool, error) {
+	added := false
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		var room models.Room
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Select("id").
+			Where("id = ?", pin.RoomID).
+			First(&room).Error
+		if err != nil {
+			return err
+		}
+
+		// Pins of deleted messages are hidden and do not take up a slot.
+		var count int64
+		err = tx.Model(&models.RoomPin{}).
+			Joins("JOIN messages ON messages.id = room_pins.message_id").
+			Where("room_pins.room_id = ? AND messages.deleted_at IS NULL", pin.RoomID).
+			Count(&count).Error
+		if err != nil {
+			return err
+		}
+		if count >= int64(limit) {
+			err := tx.Where("room_id = ? AND message_id = ?", pin.RoomID, pin.MessageID).First(pin).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrPinLimitReached
+			}
+			return err
+		}
+
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(pin)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return tx.Where("room_id = ? AND message_id = ?", pin.RoomID, pin.MessageID).First(pin).Error
+		}
+
+		added = true
+		return nil
+	})
+	return added, err
+}
+
+// RemovePin reports false when the message was not pinned.
+func (r *roomRepository) RemovePin(roomID, messageID string) (bool, error) {
+	result := r.db.Where("room_id = ? AND message_id = ?", roomID, messageID).Delete(&models.RoomPin{})
+	return result.RowsAffected > 0, result.Error
+}
+
+// ListPins returns the room's pinned messages, most recently pinned first.
+// Pins of deleted messages are left out.
+func (r *roomRepository) ListPins(roomID string) ([]*models.PinnedMessage, error) {
+	var pins []*models.PinnedMessage
+	err := r.db.Table("room_pins").
+		Select("messages.*, room_pins.pinned_by, room_pins.created_at AS pinned_at").
+		Joins("JOIN messages ON messages.id = room_pins.message_id").
+		Where("room_pins.room_id = ? AND messages.deleted_at IS NULL", roomID).
+		Order("room_pins.created_at DESC").
+		Scan(&pins).Error
+	return pins, err
+}
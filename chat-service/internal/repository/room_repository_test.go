@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
+)
+
+func TestUnreadCountsSkipHistoryBeforeJoin(t *testing.T) {
+	db := openTestDB(t)
+	rooms := NewRoomRepository(db)
+	messages := NewMessageRepository(db)
+
+	owner, joiner := uuid.New(), uuid.New()
+
+	room := &models.Room{Name: "unread-" + owner.String()[:8], CreatedBy: owner}
+	if err := rooms.Create(room); err != nil {
+		t.Fatalf("create room: %v", err)
+	}
+	t.Cleanup(func() { rooms.Delete(room.ID.String()) })
+
+	if err := rooms.AddParticipant(&models.RoomParticipant{RoomID: room.ID, UserID: owner, Role: models.RoleOwner}); err != nil {
+		t.Fatalf("add owner: %v", err)
+	}
+
+	send := func(content string) {
+		t.Helper()
+		message := &models.Message{RoomID: room.ID, UserID: owner, Username: "owner", Content: content}
+		if _, err := messages.Create(message); err != nil {
+			t.Fatalf("create message: %v", err)
+		}
+	}
+
+	for i := range 3 {
+		send(fmt.Sprintf("before join %d", i))
+	}
+
+	participant := &models.RoomParticipant{RoomID: room.ID, UserID: joiner}
+	if err := rooms.AddParticipant(participant); err != nil {
+		t.Fatalf("add participant: %v", err)
+	}
+	if participant.LastReadSeq != 3 {
+		t.Errorf("LastReadSeq = %d, want 3", participant.LastReadSeq)
+	}
+	if participant.JoinedAt.IsZero() {
+		t.Error("JoinedAt was not set")
+	}
+
+	assertUnread := func(want int64) {
+		t.Helper()
+		counts, err := rooms.UnreadCounts(joiner.String())
+		if err != nil {
+			t.Fatalf("unread counts: %v", err)
+		}
+		if len(counts) != 1 || counts[0].RoomID != room.ID {
+			t.Fatalf("counts = %+v, want one entry for room %s", counts, room.ID)
+		}
+		if counts[0].Unread != want {
+			t.Errorf("unread = %d, want %d", counts[0].Unread, want)
+		}
+	}
+
+	assertUnread(0)
+
+	send("after join")
+	assertUnread(1)
+}
@@ -3,41 +3,98 @@ package service
 import (
 	"context"
 	"errors"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
 	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/repository"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/presence"
+	"github.com/dmehra2102/go-realtime-chat/shared/pkg/logger"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type ChatService interface {
-	CreateRoom(req *models.CreateRoomRequest, userID string) (*models.Room, error)
-	ListRooms() ([]*models.Room, error)
-	JoinRoom(roomID, userID string) error
-	GetRoomMessages(roomID string, limit int) ([]*models.Message, error)
-	SaveMessage(ctx context.Context, msg *models.WebSocketMessage) error
+	CreateRoom(req *models.CreateRoomRequest, userID, username string) (*models.Room, error)
+	ListRooms(userID string, query *models.RoomQuery) (*models.RoomPage, error)
+	OpenDirectMessage(userID, username, otherUserID string) (*models.Room, bool, error)
+	JoinRoom(roomID, userID, username string) error
+	GetRoomParticipantIDs(roomID, userID string) ([]string, error)
+	GetUserRoomIDs(userID string) ([]string, error)
+	GetRoomMessages(roomID, userID string, query *models.MessageQuery) (*models.MessagePage, error)
+	GetThread(roomID, messageID, userID string, query *models.MessageQuery) (*models.ThreadPage, error)
+	GetMessagesAfter(roomID string, afterSeq int64, limit int) ([]*models.Message, error)
+	SaveMessage(ctx context.Context, msg *models.WebSocketMessage) (*models.Message, bool, error)
+	EditMessage(roomID, messageID, userID, content string) (*models.Message, error)
+	DeleteMessage(roomID, messageID, userID string) (*models.Message, bool, error)
+	PurgeDeletedMessages(before time.Time) (int64, error)
+	AddReaction(roomID, messageID, userID, emoji string) (bool, error)
+	RemoveReaction(roomID, messageID, userID, emoji string) (bool, error)
+	InviteUser(roomID, userID, inviteeID string) (*models.RoomInvitation, error)
+	CreateInviteLink(roomID, userID string, req *models.CreateInviteLinkRequest) (*models.RoomInviteLink, error)
+	AcceptInviteLink(token, userID, username string) (*models.Room, error)
+	SetParticipantRole(roomID, userID, targetID, role string) (*models.RoomParticipant, bool, error)
+	Moderate(roomID, userID, targetID, action string, req *models.ModerationRequest) (*models.ModerationLog, error)
+	UpdateRoom(roomID, userID string, req *models.UpdateRoomRequest) (*models.Room, error)
+	SetRoomArchived(roomID, userID string, archived bool) (*models.Room, error)
+	DeleteRoom(roomID, userID string) error
+	TransferOwnership(roomID, userID, targetID string) (*models.RoomParticipant, *models.RoomParticipant, error)
+	MarkRead(roomID, userID, messageID string) (*models.RoomParticipant, bool, error)
+	GetUnreadCounts(userID string) ([]models.UnreadCount, error)
+	GetMentions(userID, cursor string, limit int) (*models.MentionPage, error)
+	PinMessage(roomID, messageID, userID string) (*models.Message, *models.RoomPin, bool, error)
+	UnpinMessage(roomID, messageID, userID string) (bool, error)
+	GetPins(roomID, userID string) ([]*models.PinnedMessage, error)
+	SearchMessages(userID string, query *models.SearchQuery) (*models.SearchPage, error)
+	ExportRoom(roomID, userID string, query *models.ExportQuery) (*models.Room, MessagePager, error)
 }
 
 type chatService struct {
-	roomRepo    repository.RoomRepository
-	messageRepo repository.MessageRepository
+	roomRepo       repository.RoomRepository
+	messageRepo    repository.MessageRepository
+	reactionRepo   repository.ReactionRepository
+	inviteRepo     repository.InviteRepository
+	moderationRepo repository.ModerationRepository
+	presence       presence.Tracker
+	maxPins        int
+	logger         *logger.Logger
 }
 
-func NewChatService(roomRepo repository.RoomRepository, messageRepo repository.MessageRepository) ChatService {
+func NewChatService(roomRepo repository.RoomRepository, messageRepo repository.MessageRepository, reactionRepo repository.ReactionRepository, inviteRepo repository.InviteRepository, moderationRepo repository.ModerationRepository, tracker presence.Tracker, maxPins int, logger *logger.Logger) ChatService {
 	return &chatService{
-		roomRepo:    roomRepo,
-		messageRepo: messageRepo,
+		roomRepo:       roomRepo,
+		messageRepo:    messageRepo,
+		reactionRepo:   reactionRepo,
+		inviteRepo:     inviteRepo,
+		moderationRepo: moderationRepo,
+		presence:       tracker,
+		maxPins:        maxPins,
+		logger:         logger,
 	}
 }
 
-func (s *chatService) CreateRoom(req *models.CreateRoomRequest, userID string) (*models.Room, error) {
+func (s *chatService) CreateRoom(req *models.CreateRoomRequest, userID, username string) (*models.Room, error) {
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		return nil, errors.New("invalid user ID")
+		return nil, ErrInvalidUserID
+	}
+
+	visibility := req.Visibility
+	switch visibility {
+	case "":
+		visibility = models.VisibilityPublic
+	case models.VisibilityPublic, models.VisibilityUnlisted, models.VisibilityPrivate:
+	default:
+		return nil, ErrInvalidVisibility
 	}
 
 	room := &models.Room{
 		Name:        req.Name,
 		Description: req.Description,
+		Type:        models.RoomTypeGroup,
+		Visibility:  visibility,
 		CreatedBy:   userUUID,
 	}
 
@@ -46,8 +103,10 @@ func (s *chatService) CreateRoom(req *models.CreateRoomRequest, userID string) (
 	}
 
 	participant := &models.RoomParticipant{
-		RoomID: room.ID,
-		UserID: userUUID,
+		RoomID:   room.ID,
+		UserID:   userUUID,
+		Username: username,
+		Role:     models.RoleOwner,
 	}
 
 	if err := s.roomRepo.AddParticipant(participant); err != nil {
@@ -57,55 +116,390 @@ func (s *chatService) CreateRoom(req *models.CreateRoomRequest, userID string) (
 	return room, nil
 }
 
-func (s *chatService) ListRooms() ([]*models.Room, error) {
-	return s.roomRepo.ListAll()
+// ListRooms returns a page of public rooms or, with query.Mine, of the rooms
+// userID participates in, including private and direct ones.
+func (s *chatService) ListRooms(userID string, query *models.RoomQuery) (*models.RoomPage, error) {
+	if query.Mine {
+		if _, err := uuid.Parse(userID); err != nil {
+			return nil, ErrInvalidUserID
+		}
+	}
+
+	if query.Cursor != "" {
+		if _, err := uuid.Parse(query.Cursor); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	limit := query.Limit
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	rooms, err := s.roomRepo.List(query, userID, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.RoomPage{Rooms: rooms}
+	if len(rooms) > limit {
+		page.Rooms = rooms[:limit]
+		page.NextCursor = page.Rooms[limit-1].ID.String()
+	}
+	return page, nil
 }
 
-func (s *chatService) JoinRoom(roomID, userID string) error {
+// OpenDirectMessage returns the direct room shared by the two users, creating
+// it on first use. created reports whether a new room was made. The other
+// user's username is recorded once they join the room.
+func (s *chatService) OpenDirectMessage(userID, username, otherUserID string) (*models.Room, bool, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, false, ErrInvalidUserID
+	}
+
+	otherUUID, err := uuid.Parse(otherUserID)
+	if err != nil {
+		return nil, false, ErrInvalidUserID
+	}
+
+	if userUUID == otherUUID {
+		return nil, false, ErrDirectMessageSelf
+	}
+
+	// The key is the same whichever side opens the conversation, so the
+	// unique index on dm_key guarantees one room per pair.
+	pair := []string{userUUID.String(), otherUUID.String()}
+	sort.Strings(pair)
+	dmKey := pair[0] + ":" + pair[1]
+
+	room := &models.Room{
+		Name:       "direct",
+		Type:       models.RoomTypeDirect,
+		Visibility: models.VisibilityPrivate,
+		DMKey:      &dmKey,
+		CreatedBy:  userUUID,
+	}
+
+	return s.roomRepo.CreateDirect(room, []*models.RoomParticipant{
+		{UserID: userUUID, Username: username, Role: models.RoleMember},
+		{UserID: otherUUID, Role: models.RoleMember},
+	})
+}
+
+// JoinRoom checks that the room exists and records the user as a participant.
+// Joining a room twice is not an error. Banned users are refused. Private
+// rooms can only be joined with an invitation, which joining consumes; direct
+// rooms never have one, so only their two participants get in. Joining also
+// records username, which is what mentions are resolved against.
+func (s *chatService) JoinRoom(roomID, userID, username string) error {
 	roomUUID, err := uuid.Parse(roomID)
 	if err != nil {
-		return errors.New("invalid room ID")
+		return ErrInvalidRoomID
 	}
 
 	userUUID, err := uuid.Parse(userID)
 	if err != nil {
-		return errors.New("invalid user ID")
+		return ErrInvalidUserID
 	}
 
-	isParticipant, err := s.roomRepo.IsParticipant(roomID, userID)
+	room, err := s.findRoom(roomID)
+	if err != nil {
+		return err
+	}
+
+	banned, err := s.moderationRepo.IsBanned(roomID, userID)
 	if err != nil {
 		return err
 	}
+	if banned {
+		return ErrBanned
+	}
 
-	if isParticipant {
+	participant, err := s.roomRepo.FindParticipant(roomID, userID)
+	if err == nil {
+		if username != "" && participant.Username != username {
+			return s.roomRepo.UpdateParticipantUsername(roomID, userID, username)
+		}
 		return nil
 	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
 
-	participant := &models.RoomParticipant{
-		RoomID: roomUUID,
-		UserID: userUUID,
+	if room.Visibility == models.VisibilityPrivate {
+		accepted, err := s.inviteRepo.AcceptInvitation(roomUUID, userUUID, username)
+		if err != nil {
+			return err
+		}
+		if !accepted {
+			return ErrForbidden
+		}
+		return nil
+	}
+
+	participant = &models.RoomParticipant{
+		RoomID:   roomUUID,
+		UserID:   userUUID,
+		Username: username,
+		Role:     models.RoleMember,
 	}
 
 	return s.roomRepo.AddParticipant(participant)
 }
 
-func (s *chatService) GetRoomMessages(roomID string, limit int) ([]*models.Message, error) {
+func (s *chatService) GetRoomParticipantIDs(roomID, userID string) ([]string, error) {
+	if err := s.checkReadAccess(roomID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.roomRepo.ListParticipantIDs(roomID)
+}
+
+func (s *chatService) GetUserRoomIDs(userID string) ([]string, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	return s.roomRepo.ListRoomIDsByUser(userID)
+}
+
+// GetRoomMessages returns a page of history with reactions aggregated from
+// userID's point of view.
+func (s *chatService) GetRoomMessages(roomID, userID string, query *models.MessageQuery) (*models.MessagePage, error) {
+	if err := s.checkReadAccess(roomID, userID); err != nil {
+		return nil, err
+	}
+
+	page, err := s.messagePage(roomID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.attachReactions(page.Messages, userID); err != nil {
+		return nil, err
+	}
+
+	return page, nil
+}
+
+// GetThread returns the replies to a top-level message, paginated like room
+// history.
+func (s *chatService) GetThread(roomID, messageID, userID string, query *models.MessageQuery) (*models.ThreadPage, error) {
+	if err := s.checkReadAccess(roomID, userID); err != nil {
+		return nil, err
+	}
+
+	parent, err := s.findRoomMessage(roomID, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if parent.ParentID != nil {
+		return nil, ErrInvalidParent
+	}
+
+	query.ParentID = parent.ID.String()
+	page, err := s.messagePage(roomID, query)
+	if err != nil {
+		return nil, err
+	}
+
+	withParent := append([]*models.Message{parent}, page.Messages...)
+	if err := s.attachReactions(withParent, userID); err != nil {
+		return nil, err
+	}
+
+	return &models.ThreadPage{Parent: parent, MessagePage: *page}, nil
+}
+
+func (s *chatService) messagePage(roomID string, query *models.MessageQuery) (*models.MessagePage, error) {
+	if _, err := uuid.Parse(roomID); err != nil {
+		return nil, ErrInvalidRoomID
+	}
+
+	limit := query.Limit
 	if limit <= 0 || limit > 100 {
 		limit = 50
 	}
 
-	return s.messageRepo.FindByRoomID(roomID, limit)
+	switch {
+	case query.Around != "":
+		return s.messagesAround(roomID, query.ParentID, query.Around, limit)
+	case query.After != "":
+		cursor, err := s.resolveCursor(roomID, query.After)
+		if err != nil {
+			return nil, err
+		}
+
+		newer, err := s.messageRepo.FindAfter(roomID, query.ParentID, cursor.ID.String(), limit+1)
+		if err != nil {
+			return nil, err
+		}
+		hasNewer := len(newer) > limit
+		if hasNewer {
+			newer = newer[:limit]
+		}
+		reverseMessages(newer)
+
+		return newMessagePage(newer, cursor, true, hasNewer), nil
+	default:
+		var cursor *models.Message
+		cursorID := ""
+		if query.Before != "" {
+			var err error
+			if cursor, err = s.resolveCursor(roomID, query.Before); err != nil {
+				return nil, err
+			}
+			cursorID = cursor.ID.String()
+		}
+
+		older, err := s.messageRepo.FindBefore(roomID, query.ParentID, cursorID, limit+1)
+		if err != nil {
+			return nil, err
+		}
+		hasOlder := len(older) > limit
+		if hasOlder {
+			older = older[:limit]
+		}
+
+		return newMessagePage(older, cursor, hasOlder, cursor != nil), nil
+	}
+}
+
+// messagesAround centres a page on the target message for jump-to-message.
+func (s *chatService) messagesAround(roomID, parentID, around string, limit int) (*models.MessagePage, error) {
+	target, err := s.resolveCursor(roomID, around)
+	if err != nil {
+		return nil, err
+	}
+
+	newerWant := (limit - 1) / 2
+	olderWant := limit - 1 - newerWant
+
+	older, err := s.messageRepo.FindBefore(roomID, parentID, target.ID.String(), olderWant+1)
+	if err != nil {
+		return nil, err
+	}
+	hasOlder := len(older) > olderWant
+	if hasOlder {
+		older = older[:olderWant]
+	}
+
+	newer, err := s.messageRepo.FindAfter(roomID, parentID, target.ID.String(), newerWant+1)
+	if err != nil {
+		return nil, err
+	}
+	hasNewer := len(newer) > newerWant
+	if hasNewer {
+		newer = newer[:newerWant]
+	}
+	reverseMessages(newer)
+
+	messages := make([]*models.Message, 0, len(newer)+1+len(older))
+	messages = append(messages, newer...)
+	messages = append(messages, target)
+	messages = append(messages, older...)
+
+	return newMessagePage(messages, target, hasOlder, hasNewer), nil
+}
+
+// resolveCursor accepts either a message ID or a room sequence number.
+func (s *chatService) resolveCursor(roomID, cursor string) (*models.Message, error) {
+	var (
+		message *models.Message
+		err     error
+	)
+
+	if _, parseErr := uuid.Parse(cursor); parseErr == nil {
+		message, err = s.messageRepo.FindByID(cursor)
+	} else if seq, parseErr := strconv.ParseInt(cursor, 10, 64); parseErr == nil && seq > 0 {
+		message, err = s.messageRepo.FindBySeq(roomID, seq)
+	} else {
+		return nil, ErrInvalidCursor
+	}
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+	if message.RoomID.String() != roomID {
+		return nil, ErrMessageNotFound
+	}
+
+	return message, nil
 }
 
-func (s *chatService) SaveMessage(ctx context.Context, msg *models.WebSocketMessage) error {
+// newMessagePage sets the cursors for messages (newest first). anchor is the
+// message the query was relative to and stands in for an empty page.
+func newMessagePage(messages []*models.Message, anchor *models.Message, hasOlder, hasNewer bool) *models.MessagePage {
+	page := &models.MessagePage{Messages: messages}
+
+	if len(messages) == 0 {
+		if anchor != nil {
+			if hasOlder {
+				page.NextCursor = anchor.ID.String()
+			}
+			if hasNewer {
+				page.PrevCursor = anchor.ID.String()
+			}
+		}
+		return page
+	}
+
+	if hasOlder {
+		page.NextCursor = messages[len(messages)-1].ID.String()
+	}
+	if hasNewer {
+		page.PrevCursor = messages[0].ID.String()
+	}
+	return page
+}
+
+func reverseMessages(messages []*models.Message) {
+	for i, j := 0, len(messages)-1; i < j; i, j = i+1, j-1 {
+		messages[i], messages[j] = messages[j], messages[i]
+	}
+}
+
+func (s *chatService) GetMessagesAfter(roomID string, afterSeq int64, limit int) ([]*models.Message, error) {
+	if _, err := uuid.Parse(roomID); err != nil {
+		return nil, ErrInvalidRoomID
+	}
+
+	return s.messageRepo.FindAfterSeq(roomID, afterSeq, limit)
+}
+
+// SaveMessage persists msg and reports whether a new row was written. A retry
+// carrying a client_msg_id the user already sent returns the original message
+// with created set to false. New messages come back with their resolved
+// mentions.
+func (s *chatService) SaveMessage(ctx context.Context, msg *models.WebSocketMessage) (*models.Message, bool, error) {
 	roomUUID, err := uuid.Parse(msg.RoomID)
 	if err != nil {
-		return errors.New("invalid room ID")
+		return nil, false, ErrInvalidRoomID
 	}
 
 	userUUID, err := uuid.Parse(msg.UserID)
 	if err != nil {
-		return errors.New("invalid user ID")
+		return nil, false, ErrInvalidUserID
+	}
+
+	if strings.TrimSpace(msg.Content) == "" {
+		return nil, false, ErrEmptyMessage
+	}
+
+	if len(msg.ClientMsgID) > models.MaxClientMsgIDLength {
+		return nil, false, ErrClientMsgIDTooLong
+	}
+
+	if _, err := s.writableRoom(msg.RoomID); err != nil {
+		return nil, false, err
+	}
+
+	if err := s.checkCanPost(msg.RoomID, msg.UserID); err != nil {
+		return nil, false, err
 	}
 
 	message := &models.Message{
@@ -115,5 +509,218 @@ func (s *chatService) SaveMessage(ctx context.Context, msg *models.WebSocketMess
 		Content:  msg.Content,
 	}
 
-	return s.messageRepo.Create(message)
+	if msg.ParentID != "" {
+		parent, err := s.findRoomMessage(msg.RoomID, msg.ParentID)
+		if err != nil {
+			if errors.Is(err, ErrMessageNotFound) {
+				return nil, false, ErrInvalidParent
+			}
+			return nil, false, err
+		}
+		// Threads are one level deep.
+		if parent.ParentID != nil {
+			return nil, false, ErrInvalidParent
+		}
+		message.ParentID = &parent.ID
+	}
+	if msg.ClientMsgID != "" {
+		message.ClientMsgID = &msg.ClientMsgID
+	}
+
+	// Mentions are a notification extra; failing to resolve them must not
+	// stop the message itself from being sent.
+	message.Mentions, err = s.resolveMentions(ctx, msg.RoomID, userUUID, msg.Content)
+	if err != nil {
+		s.logger.Error("Failed to resolve mentions", "roomID", msg.RoomID, "error", err)
+		message.Mentions = nil
+	}
+
+	created, err := s.messageRepo.Create(message)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrRoomNotFound
+		}
+		return nil, false, err
+	}
+
+	if !created {
+		existing, err := s.messageRepo.FindByClientMsgID(msg.UserID, msg.ClientMsgID)
+		if err != nil {
+			return nil, false, err
+		}
+		return existing, false, nil
+	}
+
+	return message, true, nil
+}
+
+// EditMessage changes the content of a message. Only its author may edit it,
+// and only while they may still post in the room.
+func (s *chatService) EditMessage(roomID, messageID, userID, content string) (*models.Message, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	if strings.TrimSpace(content) == "" {
+		return nil, ErrEmptyMessage
+	}
+
+	message, err := s.findWritableMessage(roomID, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	if message.UserID != userUUID {
+		return nil, ErrForbidden
+	}
+
+	if message.DeletedAt != nil {
+		return nil, ErrMessageDeleted
+	}
+
+	// An edit reaches the room like a new message does, so the same people
+	// may make it.
+	if err := s.checkCanPost(roomID, userID); err != nil {
+		return nil, err
+	}
+
+	return s.messageRepo.UpdateContent(messageID, userUUID, content)
+}
+
+// DeleteMessage tombstones a message. Authors may delete their own messages;
+// deleting someone else's takes permDeleteMessages. deleted is false when the
+// message already was a tombstone, which is returned as it is.
+func (s *chatService) DeleteMessage(roomID, messageID, userID string) (*models.Message, bool, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, false, ErrInvalidUserID
+	}
+
+	message, err := s.findWritableMessage(roomID, messageID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if message.UserID != userUUID {
+		if _, err := s.authorize(roomID, userID, permDeleteMessages); err != nil {
+			return nil, false, err
+		}
+	}
+
+	return s.messageRepo.SoftDelete(messageID, userUUID)
+}
+
+func (s *chatService) PurgeDeletedMessages(before time.Time) (int64, error) {
+	return s.messageRepo.PurgeDeleted(before)
+}
+
+func (s *chatService) findRoom(roomID string) (*models.Room, error) {
+	room, err := s.roomRepo.FindByID(roomID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoomNotFound
+		}
+		return nil, err
+	}
+	return room, nil
+}
+
+// checkReadAccess makes sure the room exists, that userID is not banned from
+// it and, for private rooms, that userID is one of its participants.
+func (s *chatService) checkReadAccess(roomID, userID string) error {
+	if _, err := uuid.Parse(roomID); err != nil {
+		return ErrInvalidRoomID
+	}
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return ErrInvalidUserID
+	}
+
+	room, err := s.findRoom(roomID)
+	if err != nil {
+		return err
+	}
+
+	banned, err := s.moderationRepo.IsBanned(roomID, userID)
+	if err != nil {
+		return err
+	}
+	if banned {
+		return ErrBanned
+	}
+
+	if room.Visibility != models.VisibilityPrivate {
+		return nil
+	}
+
+	isParticipant, err := s.roomRepo.IsParticipant(roomID, userID)
+	if err != nil {
+		return err
+	}
+	if !isParticipant {
+		return ErrForbidden
+	}
+	return nil
+}
+
+// checkCanPost makes sure userID may currently write to the room: they must
+// be one of its participants and not muted. Bans remove the participant, so
+// for someone who is not one the ban is checked to give the right reason.
+func (s *chatService) checkCanPost(roomID, userID string) error {
+	participant, err := s.roomRepo.FindParticipant(roomID, userID)
+	if err != nil {
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		banned, err := s.moderationRepo.IsBanned(roomID, userID)
+		if err != nil {
+			return err
+		}
+		if banned {
+			return ErrBanned
+		}
+		return ErrNotInRoom
+	}
+
+	if participant.MutedUntil != nil && participant.MutedUntil.After(time.Now()) {
+		return ErrMuted
+	}
+	return nil
+}
+
+// findWritableMessage is findRoomMessage for operations that change the
+// message, which archived rooms do not allow.
+func (s *chatService) findWritableMessage(roomID, messageID string) (*models.Message, error) {
+	if _, err := uuid.Parse(roomID); err != nil {
+		return nil, ErrInvalidRoomID
+	}
+
+	if _, err := s.writableRoom(roomID); err != nil {
+		return nil, err
+	}
+
+	return s.findRoomMessage(roomID, messageID)
+}
+
+// findRoomMessage loads a message and makes sure it belongs to roomID.
+func (s *chatService) findRoomMessage(roomID, messageID string) (*models.Message, error) {
+	if _, err := uuid.Parse(messageID); err != nil {
+		return nil, ErrMessageNotFound
+	}
+
+	message, err := s.messageRepo.FindByID(messageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrMessageNotFound
+		}
+		return nil, err
+	}
+
+	if message.RoomID.String() != roomID {
+		return nil, ErrMessageNotFound
+	}
+
+	return message, nil
 }
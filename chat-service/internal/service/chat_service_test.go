@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/repository"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeMessageRepo serves lookups from a fixed set of messages. Methods the
+// tests do not use fall through to the nil embedded interface and panic.
+type fakeMessageRepo struct {
+	repository.MessageRepository
+	messages []*models.Message
+}
+
+func (f *fakeMessageRepo) FindByID(id string) (*models.Message, error) {
+	for _, m := range f.messages {
+		if m.ID.String() == id {
+			return m, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeMessageRepo) FindBySeq(roomID string, seq int64) (*models.Message, error) {
+	for _, m := range f.messages {
+		if m.RoomID.String() == roomID && m.Seq == seq {
+			return m, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeMessageRepo) UpdateContent(id string, editedBy uuid.UUID, content string) (*models.Message, error) {
+	message, err := f.FindByID(id)
+	if err != nil {
+		return nil, err
+	}
+	message.Content = content
+	return message, nil
+}
+
+// fakeRoomRepo holds one room and its participants, keyed by user ID.
+type fakeRoomRepo struct {
+	repository.RoomRepository
+	room         *models.Room
+	participants map[string]*models.RoomParticipant
+}
+
+func (f *fakeRoomRepo) FindByID(id string) (*models.Room, error) {
+	if f.room.ID.String() != id {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return f.room, nil
+}
+
+func (f *fakeRoomRepo) FindParticipant(roomID, userID string) (*models.RoomParticipant, error) {
+	participant, ok := f.participants[userID]
+	if f.room.ID.String() != roomID || !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return participant, nil
+}
+
+// fakeModerationRepo knows which user IDs are banned.
+type fakeModerationRepo struct {
+	repository.ModerationRepository
+	banned map[string]bool
+}
+
+func (f *fakeModerationRepo) IsBanned(roomID, userID string) (bool, error) {
+	return f.banned[userID], nil
+}
+
+func TestResolveCursor(t *testing.T) {
+	roomID, otherRoomID := uuid.New(), uuid.New()
+	first := &models.Message{ID: uuid.New(), RoomID: roomID, Seq: 1}
+	second := &models.Message{ID: uuid.New(), RoomID: roomID, Seq: 2}
+	elsewhere := &models.Message{ID: uuid.New(), RoomID: otherRoomID, Seq: 1}
+
+	s := &chatService{messageRepo: &fakeMessageRepo{messages: []*models.Message{first, second, elsewhere}}}
+
+	tests := []struct {
+		name    string
+		cursor  string
+		want    *models.Message
+		wantErr error
+	}{
+		{name: "message ID", cursor: second.ID.String(), want: second},
+		{name: "sequence number", cursor: "1", want: first},
+		{name: "unknown message ID", cursor: uuid.NewString(), wantErr: ErrMessageNotFound},
+		{name: "unknown sequence number", cursor: "99", wantErr: ErrMessageNotFound},
+		{name: "message from another room", cursor: elsewhere.ID.String(), wantErr: ErrMessageNotFound},
+		{name: "zero", cursor: "0", wantErr: ErrInvalidCursor},
+		{name: "negative", cursor: "-1", wantErr: ErrInvalidCursor},
+		{name: "garbage", cursor: "abc", wantErr: ErrInvalidCursor},
+		{name: "empty", cursor: "", wantErr: ErrInvalidCursor},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.resolveCursor(roomID.String(), tt.cursor)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("resolveCursor(%q) error = %v, want %v", tt.cursor, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("resolveCursor(%q) = %v, want %v", tt.cursor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewMessagePageCursors(t *testing.T) {
+	newest := &models.Message{ID: uuid.New(), Seq: 3}
+	middle := &models.Message{ID: uuid.New(), Seq: 2}
+	oldest := &models.Message{ID: uuid.New(), Seq: 1}
+	anchor := &models.Message{ID: uuid.New(), Seq: 10}
+
+	tests := []struct {
+		name     string
+		messages []*models.Message
+		anchor   *models.Message
+		hasOlder bool
+		hasNewer bool
+		wantNext string
+		wantPrev string
+	}{
+		{
+			name:     "only page",
+			messages: []*models.Message{newest, middle, oldest},
+		},
+		{
+			name:     "older history remains",
+			messages: []*models.Message{newest, middle, oldest},
+			hasOlder: true,
+			wantNext: oldest.ID.String(),
+		},
+		{
+			name:     "newer history remains",
+			messages: []*models.Message{newest, middle, oldest},
+			hasNewer: true,
+			wantPrev: newest.ID.String(),
+		},
+		{
+			name:     "both directions",
+			messages: []*models.Message{newest, middle, oldest},
+			hasOlder: true,
+			hasNewer: true,
+			wantNext: oldest.ID.String(),
+			wantPrev: newest.ID.String(),
+		},
+		{
+			name:     "empty page falls back to the anchor",
+			anchor:   anchor,
+			hasOlder: true,
+			hasNewer: true,
+			wantNext: anchor.ID.String(),
+			wantPrev: anchor.ID.String(),
+		},
+		{
+			name: "empty page without an anchor",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			page := newMessagePage(tt.messages, tt.anchor, tt.hasOlder, tt.hasNewer)
+			if page.NextCursor != tt.wantNext {
+				t.Errorf("NextCursor = %q, want %q", page.NextCursor, tt.wantNext)
+			}
+			if page.PrevCursor != tt.wantPrev {
+				t.Errorf("PrevCursor = %q, want %q", page.PrevCursor, tt.wantPrev)
+			}
+		})
+	}
+}
+
+func TestEditMessageRequiresPostingRights(t *testing.T) {
+	room := &models.Room{ID: uuid.New()}
+	authorID := uuid.New()
+	past, future := time.Now().Add(-time.Minute), time.Now().Add(time.Hour)
+
+	tests := []struct {
+		name        string
+		participant *models.RoomParticipant
+		banned      bool
+		wantErr     error
+	}{
+		{name: "participant", participant: &models.RoomParticipant{}},
+		{name: "mute has expired", participant: &models.RoomParticipant{MutedUntil: &past}},
+		{name: "muted", participant: &models.RoomParticipant{MutedUntil: &future}, wantErr: ErrMuted},
+		{name: "kicked", wantErr: ErrNotInRoom},
+		{name: "banned", banned: true, wantErr: ErrBanned},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			message := &models.Message{ID: uuid.New(), RoomID: room.ID, UserID: authorID, Content: "before"}
+			participants := make(map[string]*models.RoomParticipant)
+			if tt.participant != nil {
+				participants[authorID.String()] = tt.participant
+			}
+
+			s := &chatService{
+				roomRepo:       &fakeRoomRepo{room: room, participants: participants},
+				messageRepo:    &fakeMessageRepo{messages: []*models.Message{message}},
+				moderationRepo: &fakeModerationRepo{banned: map[string]bool{authorID.String(): tt.banned}},
+			}
+
+			_, err := s.EditMessage(room.ID.String(), message.ID.String(), authorID.String(), "after")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("EditMessage() error = %v, want %v", err, tt.wantErr)
+			}
+
+			want := "after"
+			if tt.wantErr != nil {
+				want = "before"
+			}
+			if message.Content != want {
+				t.Errorf("content = %q, want %q", message.Content, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import "errors"
+
+var (
+	ErrInvalidRoomID       = errors.New("invalid room ID")
+	ErrInvalidUserID       = errors.New("invalid user ID")
+	ErrRoomNotFound        = errors.New("room not found")
+	ErrMessageNotFound     = errors.New("message not found")
+	ErrMessageDeleted      = errors.New("message has been deleted")
+	ErrInvalidCursor       = errors.New("invalid cursor")
+	ErrNotInRoom           = errors.New("you have not joined this room")
+	ErrForbidden           = errors.New("you are not allowed to do that")
+	ErrInvalidEmoji        = errors.New("invalid emoji")
+	ErrInvalidParent       = errors.New("replies must point to a top-level message in the same room")
+	ErrEmptyMessage        = errors.New("message content is required")
+	ErrClientMsgIDTooLong  = errors.New("client_msg_id is too long")
+	ErrDirectMessageSelf   = errors.New("cannot open a direct message with yourself")
+	ErrInvalidVisibility   = errors.New("visibility must be public, unlisted or private")
+	ErrInvalidInvite       = errors.New("invite is invalid or has expired")
+	ErrInvalidInviteLink   = errors.New("invalid invite link options")
+	ErrInvalidRole         = errors.New("role must be admin or member")
+	ErrParticipantNotFound = errors.New("user is not a participant of this room")
+	ErrBanned              = errors.New("you are banned from this room")
+	ErrMuted               = errors.New("you are muted in this room")
+	ErrInvalidModeration   = errors.New("action must be kick, ban or mute")
+	ErrInvalidMuteDuration = errors.New("mute duration must be between 1 second and 30 days")
+	ErrInvalidRoomName     = errors.New("room name must be between 3 and 100 characters")
+	ErrRoomArchived        = errors.New("room is archived")
+	ErrInvalidTransfer     = errors.New("ownership can only be transferred to another participant")
+	ErrTooManyPins         = errors.New("room has reached its pin limit")
+	ErrInvalidSearch       = errors.New("search query must be between 1 and 200 characters")
+	ErrInvalidExportRange  = errors.New("export range must end after it starts")
+)
+
+// publicErrors are safe to show to clients as-is.
+var publicErrors = []error{
+	ErrInvalidRoomID,
+	ErrInvalidUserID,
+	ErrRoomNotFound,
+	ErrMessageNotFound,
+	ErrMessageDeleted,
+	ErrInvalidCursor,
+	ErrNotInRoom,
+	ErrForbidden,
+	ErrInvalidEmoji,
+	ErrInvalidParent,
+	ErrEmptyMessage,
+	ErrClientMsgIDTooLong,
+	ErrDirectMessageSelf,
+	ErrInvalidVisibility,
+	ErrInvalidInvite,
+	ErrInvalidInviteLink,
+	ErrInvalidRole,
+	ErrParticipantNotFound,
+	ErrBanned,
+	ErrMuted,
+	ErrInvalidModeration,
+	ErrInvalidMuteDuration,
+	ErrInvalidRoomName,
+	ErrRoomArchived,
+	ErrInvalidTransfer,
+	ErrTooManyPins,
+	ErrInvalidSearch,
+	ErrInvalidExportRange,
+}
+
+// ErrorMessage returns err's text when it is one of the errors above and
+// fallback otherwise, so storage errors never reach the client.
+func ErrorMessage(err error, fallback string) string {
+	for _, target := range publicErrors {
+		if errors.Is(err, target) {
+			return target.Error()
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,50 @@
+package service
+
+import "github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+
+const exportPageSize = 500
+
+// MessagePager returns the next page of an export, oldest first. An empty
+// page means the export is complete.
+type MessagePager func() ([]*models.Message, error)
+
+// ExportRoom checks that userID may export the room and returns a pager over
+// its messages, deleted ones included, so the caller can stream them without
+// holding the whole history in memory.
+func (s *chatService) ExportRoom(roomID, userID string, query *models.ExportQuery) (*models.Room, MessagePager, error) {
+	if _, err := s.authorize(roomID, userID, permExportRoom); err != nil {
+		return nil, nil, err
+	}
+
+	if query.From != nil && query.To != nil && !query.To.After(*query.From) {
+		return nil, nil, ErrInvalidExportRange
+	}
+
+	room, err := s.findRoom(roomID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var afterSeq int64
+	done := false
+	next := func() ([]*models.Message, error) {
+		if done {
+			return nil, nil
+		}
+
+		messages, err := s.messageRepo.FindRange(roomID, afterSeq, query.From, query.To, exportPageSize)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(messages) < exportPageSize {
+			done = true
+		}
+		if len(messages) > 0 {
+			afterSeq = messages[len(messages)-1].Seq
+		}
+		return messages, nil
+	}
+
+	return room, next, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const inviteTokenBytes = 24
+
+// InviteUser invites a named user into a room. Direct rooms cannot take a
+// third member.
+func (s *chatService) InviteUser(roomID, userID, inviteeID string) (*models.RoomInvitation, error) {
+	inviteeUUID, err := uuid.Parse(inviteeID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	room, inviterUUID, err := s.inviteSource(roomID, userID, permInvite)
+	if err != nil {
+		return nil, err
+	}
+
+	invitation := &models.RoomInvitation{
+		RoomID:    room.ID,
+		UserID:    inviteeUUID,
+		InvitedBy: inviterUUID,
+	}
+
+	if err := s.inviteRepo.CreateInvitation(invitation); err != nil {
+		return nil, err
+	}
+
+	return invitation, nil
+}
+
+// CreateInviteLink issues a shareable token for the room. Anyone holding it
+// can join, so unlike inviting a named user it takes an admin.
+func (s *chatService) CreateInviteLink(roomID, userID string, req *models.CreateInviteLinkRequest) (*models.RoomInviteLink, error) {
+	ttl := time.Duration(req.ExpiresIn) * time.Second
+	if ttl == 0 {
+		ttl = models.DefaultInviteLinkTTL
+	}
+	if ttl < 0 || ttl > models.MaxInviteLinkTTL || req.MaxUses < 0 {
+		return nil, ErrInvalidInviteLink
+	}
+
+	room, creatorUUID, err := s.inviteSource(roomID, userID, permCreateInviteLink)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := newInviteToken()
+	if err != nil {
+		return nil, err
+	}
+
+	link := &models.RoomInviteLink{
+		Token:     token,
+		RoomID:    room.ID,
+		CreatedBy: creatorUUID,
+		ExpiresAt: time.Now().Add(ttl),
+		MaxUses:   req.MaxUses,
+	}
+
+	if err := s.inviteRepo.CreateLink(link); err != nil {
+		return nil, err
+	}
+
+	return link, nil
+}
+
+// AcceptInviteLink makes the user a participant of the link's room, after
+// which they can join it over the websocket.
+func (s *chatService) AcceptInviteLink(token, userID, username string) (*models.Room, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	roomID, err := s.inviteRepo.RedeemLink(token, userUUID, username)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidInvite
+		}
+		return nil, err
+	}
+
+	return s.findRoom(roomID.String())
+}
+
+// inviteSource checks that userID holds perm and that the room can take more
+// members.
+func (s *chatService) inviteSource(roomID, userID string, perm permission) (*models.Room, uuid.UUID, error) {
+	participant, err := s.authorize(roomID, userID, perm)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	room, err := s.findRoom(roomID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	if room.Type == models.RoomTypeDirect {
+		return nil, uuid.Nil, ErrForbidden
+	}
+
+	return room, participant.UserID, nil
+}
+
+func newInviteToken() (string, error) {
+	b := make([]byte, inviteTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
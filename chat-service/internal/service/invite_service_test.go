@@ -0,0 +1,60 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+// fakeInviteRepo records the links it is asked to create.
+type fakeInviteRepo struct {
+	repository.InviteRepository
+	links []*models.RoomInviteLink
+}
+
+func (f *fakeInviteRepo) CreateLink(link *models.RoomInviteLink) error {
+	f.links = append(f.links, link)
+	return nil
+}
+
+func TestCreateInviteLinkRequiresAdmin(t *testing.T) {
+	room := &models.Room{ID: uuid.New(), Visibility: models.VisibilityPrivate}
+
+	tests := []struct {
+		role    string
+		wantErr error
+	}{
+		{role: models.RoleMember, wantErr: ErrForbidden},
+		{role: models.RoleAdmin},
+		{role: models.RoleOwner},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.role, func(t *testing.T) {
+			userID := uuid.New()
+			invites := &fakeInviteRepo{}
+			s := &chatService{
+				roomRepo: &fakeRoomRepo{room: room, participants: map[string]*models.RoomParticipant{
+					userID.String(): {RoomID: room.ID, UserID: userID, Role: tt.role},
+				}},
+				inviteRepo: invites,
+			}
+
+			_, err := s.CreateInviteLink(room.ID.String(), userID.String(), &models.CreateInviteLinkRequest{})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("CreateInviteLink() error = %v, want %v", err, tt.wantErr)
+			}
+
+			wantLinks := 1
+			if tt.wantErr != nil {
+				wantLinks = 0
+			}
+			if len(invites.links) != wantLinks {
+				t.Errorf("created %d links, want %d", len(invites.links), wantLinks)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SetParticipantRole promotes a member to admin or demotes an admin to
+// member. Only the owner manages roles, and the owner's own role only changes
+// through an ownership transfer. changed is false when the target already had
+// the role.
+func (s *chatService) SetParticipantRole(roomID, userID, targetID, role string) (*models.RoomParticipant, bool, error) {
+	if role != models.RoleAdmin && role != models.RoleMember {
+		return nil, false, ErrInvalidRole
+	}
+
+	actor, err := s.authorize(roomID, userID, permManageRoles)
+	if err != nil {
+		return nil, false, err
+	}
+
+	target, err := s.findParticipant(roomID, targetID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	if !outranks(actor, target) {
+		return nil, false, ErrForbidden
+	}
+
+	if target.Role == role {
+		return target, false, nil
+	}
+
+	if err := s.roomRepo.UpdateParticipantRole(roomID, targetID, role); err != nil {
+		return nil, false, err
+	}
+
+	target.Role = role
+	return target, true, nil
+}
+
+func (s *chatService) findParticipant(roomID, userID string) (*models.RoomParticipant, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	participant, err := s.roomRepo.FindParticipant(roomID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrParticipantNotFound
+		}
+		return nil, err
+	}
+	return participant, nil
+}
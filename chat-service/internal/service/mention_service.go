@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"regexp"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/presence"
+	"github.com/google/uuid"
+)
+
+const maxMentionedUsernames = 50
+
+// mentionPattern matches @name where the @ does not follow a word character,
+// so email addresses are not mistaken for mentions. Names may contain dots
+// and dashes but not end with them, leaving sentence punctuation out.
+var mentionPattern = regexp.MustCompile(`(?:^|[^A-Za-z0-9_])@([A-Za-z0-9_](?:[A-Za-z0-9_.-]*[A-Za-z0-9_])?)`)
+
+// parseMentions lists the distinct usernames mentioned in content and whether
+// @here or @room appear.
+func parseMentions(content string) (usernames []string, here, room bool) {
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(content, -1) {
+		name := match[1]
+		switch {
+		case name == models.MentionHere:
+			here = true
+		case name == models.MentionRoom:
+			room = true
+		case !seen[name] && len(seen) < maxMentionedUsernames:
+			seen[name] = true
+			usernames = append(usernames, name)
+		}
+	}
+	return usernames, here, room
+}
+
+// resolveMentions turns the mentions in content into one row per mentioned
+// participant. A user named directly is recorded as such even if @here or
+// @room also reaches them, and authors never mention themselves.
+func (s *chatService) resolveMentions(ctx context.Context, roomID string, authorID uuid.UUID, content string) ([]models.MessageMention, error) {
+	usernames, here, room := parseMentions(content)
+	if len(usernames) == 0 && !here && !room {
+		return nil, nil
+	}
+
+	kinds := make(map[uuid.UUID]string)
+
+	named, err := s.roomRepo.FindParticipantIDsByUsername(roomID, usernames)
+	if err != nil {
+		return nil, err
+	}
+	for _, userID := range named {
+		kinds[userID] = models.MentionUser
+	}
+
+	if here || room {
+		participantIDs, err := s.roomRepo.ListParticipantIDs(roomID)
+		if err != nil {
+			return nil, err
+		}
+
+		// @room reaches everyone, so @here only matters without it.
+		var online map[string]string
+		if !room {
+			if online, err = s.presence.Statuses(ctx, participantIDs); err != nil {
+				return nil, err
+			}
+		}
+
+		for _, id := range participantIDs {
+			userID, err := uuid.Parse(id)
+			if err != nil || kinds[userID] != "" {
+				continue
+			}
+			switch {
+			case room:
+				kinds[userID] = models.MentionRoom
+			case online[id] == presence.StatusOnline:
+				kinds[userID] = models.MentionHere
+			}
+		}
+	}
+
+	delete(kinds, authorID)
+
+	mentions := make([]models.MessageMention, 0, len(kinds))
+	for userID, kind := range kinds {
+		mentions = append(mentions, models.MessageMention{UserID: userID, Kind: kind})
+	}
+	return mentions, nil
+}
+
+// GetMentions lists messages mentioning the user, newest first. cursor is the
+// message ID of the last mention on the previous page.
+func (s *chatService) GetMentions(userID, cursor string, limit int) (*models.MentionPage, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	if cursor != "" {
+		if _, err := uuid.Parse(cursor); err != nil {
+			return nil, ErrInvalidCursor
+		}
+	}
+
+	if limit <= 0 || limit > 100 {
+		limit = 50
+	}
+
+	mentions, err := s.messageRepo.FindMentions(userID, cursor, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.MentionPage{Mentions: mentions}
+	if page.Mentions == nil {
+		page.Mentions = []*models.MentionedMessage{}
+	}
+	if len(mentions) > limit {
+		page.Mentions = mentions[:limit]
+		page.NextCursor = page.Mentions[limit-1].ID.String()
+	}
+	return page, nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		usernames []string
+		here      bool
+		room      bool
+	}{
+		{name: "no mentions", content: "hello there"},
+		{name: "single", content: "hi @alice", usernames: []string{"alice"}},
+		{name: "at start", content: "@alice hi", usernames: []string{"alice"}},
+		{name: "several in order", content: "@bob and @alice", usernames: []string{"bob", "alice"}},
+		{name: "duplicates once", content: "@alice @alice", usernames: []string{"alice"}},
+		{name: "email is not a mention", content: "write to bob@example.com"},
+		{name: "trailing punctuation", content: "thanks @alice.", usernames: []string{"alice"}},
+		{name: "trailing dash", content: "ping @alice-", usernames: []string{"alice"}},
+		{name: "inner dots and dashes", content: "cc @al.ice-b", usernames: []string{"al.ice-b"}},
+		{name: "in parentheses", content: "(@alice)", usernames: []string{"alice"}},
+		{name: "underscore", content: "@_bot", usernames: []string{"_bot"}},
+		{name: "bare at sign", content: "meet @ noon"},
+		{name: "here", content: "@here standup", here: true},
+		{name: "room", content: "@room standup", room: true},
+		{name: "here, room and a name", content: "@here @room @alice", usernames: []string{"alice"}, here: true, room: true},
+		{name: "case is kept", content: "@Alice", usernames: []string{"Alice"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			usernames, here, room := parseMentions(tt.content)
+			if !slices.Equal(usernames, tt.usernames) {
+				t.Errorf("usernames = %q, want %q", usernames, tt.usernames)
+			}
+			if here != tt.here {
+				t.Errorf("here = %v, want %v", here, tt.here)
+			}
+			if room != tt.room {
+				t.Errorf("room = %v, want %v", room, tt.room)
+			}
+		})
+	}
+}
+
+func TestParseMentionsCapsUsernames(t *testing.T) {
+	content := ""
+	for i := range maxMentionedUsernames + 10 {
+		content += fmt.Sprintf("@user%d ", i)
+	}
+
+	usernames, _, _ := parseMentions(content)
+	if len(usernames) != maxMentionedUsernames {
+		t.Errorf("got %d usernames, want %d", len(usernames), maxMentionedUsernames)
+	}
+}
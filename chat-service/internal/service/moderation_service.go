@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var moderationPermissions = map[string]permission{
+	models.ModerationKick: permKick,
+	models.ModerationBan:  permBan,
+	models.ModerationMute: permMute,
+}
+
+// Moderate kicks, bans or mutes targetID and returns the moderation log entry
+// describing what was done. Moderators can only act on participants below
+// their own role; a ban may also target someone who never joined.
+func (s *chatService) Moderate(roomID, userID, targetID, action string, req *models.ModerationRequest) (*models.ModerationLog, error) {
+	perm, ok := moderationPermissions[action]
+	if !ok {
+		return nil, ErrInvalidModeration
+	}
+
+	var expiresAt *time.Time
+	if action == models.ModerationMute {
+		duration := time.Duration(req.Duration) * time.Second
+		if duration <= 0 || duration > models.MaxMuteDuration {
+			return nil, ErrInvalidMuteDuration
+		}
+		until := time.Now().Add(duration)
+		expiresAt = &until
+	}
+
+	targetUUID, err := uuid.Parse(targetID)
+	if err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	actor, err := s.authorize(roomID, userID, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	target, err := s.roomRepo.FindParticipant(roomID, targetID)
+	switch {
+	case err == nil:
+	case errors.Is(err, gorm.ErrRecordNotFound) && action == models.ModerationBan:
+		target = &models.RoomParticipant{Role: models.RoleMember}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, ErrParticipantNotFound
+	default:
+		return nil, err
+	}
+
+	if !outranks(actor, target) {
+		return nil, ErrForbidden
+	}
+
+	entry := &models.ModerationLog{
+		RoomID:    actor.RoomID,
+		ActorID:   actor.UserID,
+		TargetID:  targetUUID,
+		Action:    action,
+		Reason:    req.Reason,
+		ExpiresAt: expiresAt,
+	}
+
+	switch action {
+	case models.ModerationKick:
+		err = s.moderationRepo.Kick(entry)
+	case models.ModerationBan:
+		err = s.moderationRepo.Ban(entry)
+	case models.ModerationMute:
+		err = s.moderationRepo.Mute(entry)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
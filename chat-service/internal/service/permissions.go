@@ -0,0 +1,89 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// permission is something a participant may do in a room beyond reading and
+// posting their own messages.
+type permission int
+
+const (
+	permInvite permission = iota
+	permCreateInviteLink
+	permDeleteMessages
+	permPin
+	permKick
+	permBan
+	permMute
+	permEditRoom
+	permExportRoom
+	permChangeSettings
+	permManageRoles
+	permDeleteRoom
+	permTransferOwnership
+)
+
+// minimumRole is the least role that holds each permission.
+var minimumRole = map[permission]string{
+	permInvite:            models.RoleMember,
+	permCreateInviteLink:  models.RoleAdmin,
+	permDeleteMessages:    models.RoleAdmin,
+	permPin:               models.RoleAdmin,
+	permKick:              models.RoleAdmin,
+	permBan:               models.RoleAdmin,
+	permMute:              models.RoleAdmin,
+	permEditRoom:          models.RoleAdmin,
+	permExportRoom:        models.RoleAdmin,
+	permChangeSettings:    models.RoleOwner,
+	permManageRoles:       models.RoleOwner,
+	permDeleteRoom:        models.RoleOwner,
+	permTransferOwnership: models.RoleOwner,
+}
+
+var roleRank = map[string]int{
+	models.RoleMember: 1,
+	models.RoleAdmin:  2,
+	models.RoleOwner:  3,
+}
+
+// authorize is the single place that decides whether userID may do perm in
+// the room. It returns the caller's participant row so operations aimed at
+// another participant can compare roles with outranks.
+func (s *chatService) authorize(roomID, userID string, perm permission) (*models.RoomParticipant, error) {
+	if _, err := uuid.Parse(roomID); err != nil {
+		return nil, ErrInvalidRoomID
+	}
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	if _, err := s.findRoom(roomID); err != nil {
+		return nil, err
+	}
+
+	participant, err := s.roomRepo.FindParticipant(roomID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrNotInRoom
+		}
+		return nil, err
+	}
+
+	if roleRank[participant.Role] < roleRank[minimumRole[perm]] {
+		return nil, ErrForbidden
+	}
+
+	return participant, nil
+}
+
+// outranks reports whether actor may act on target: an admin can act on
+// members but not on other admins or the owner.
+func outranks(actor, target *models.RoomParticipant) bool {
+	return roleRank[actor.Role] > roleRank[target.Role]
+}
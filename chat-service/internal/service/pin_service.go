@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/repository"
+)
+
+// PinMessage pins a message in its room. changed is false when it was
+// already pinned, and the existing pin is returned.
+func (s *chatService) PinMessage(roomID, messageID, userID string) (*models.Message, *models.RoomPin, bool, error) {
+	actor, err := s.authorize(roomID, userID, permPin)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	message, err := s.findWritableMessage(roomID, messageID)
+	if err != nil {
+		return nil, nil, false, err
+	}
+
+	if message.DeletedAt != nil {
+		return nil, nil, false, ErrMessageDeleted
+	}
+
+	pin := &models.RoomPin{
+		RoomID:    message.RoomID,
+		MessageID: message.ID,
+		PinnedBy:  actor.UserID,
+	}
+
+	added, err := s.roomRepo.AddPin(pin, s.maxPins)
+	if err != nil {
+		if errors.Is(err, repository.ErrPinLimitReached) {
+			return nil, nil, false, ErrTooManyPins
+		}
+		return nil, nil, false, err
+	}
+
+	return message, pin, added, nil
+}
+
+// UnpinMessage reports whether the message was pinned.
+func (s *chatService) UnpinMessage(roomID, messageID, userID string) (bool, error) {
+	if _, err := s.authorize(roomID, userID, permPin); err != nil {
+		return false, err
+	}
+
+	if _, err := s.findWritableMessage(roomID, messageID); err != nil {
+		return false, err
+	}
+
+	return s.roomRepo.RemovePin(roomID, messageID)
+}
+
+func (s *chatService) GetPins(roomID, userID string) ([]*models.PinnedMessage, error) {
+	if err := s.checkReadAccess(roomID, userID); err != nil {
+		return nil, err
+	}
+
+	pins, err := s.roomRepo.ListPins(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if pins == nil {
+		pins = []*models.PinnedMessage{}
+	}
+	return pins, nil
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"strings"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
+)
+
+// AddReaction reports whether the reaction is new, so callers only announce
+// actual changes.
+func (s *chatService) AddReaction(roomID, messageID, userID, emoji string) (bool, error) {
+	message, userUUID, err := s.reactionTarget(roomID, messageID, userID, emoji)
+	if err != nil {
+		return false, err
+	}
+
+	return s.reactionRepo.Add(&models.MessageReaction{
+		MessageID: message.ID,
+		UserID:    userUUID,
+		Emoji:     emoji,
+	})
+}
+
+func (s *chatService) RemoveReaction(roomID, messageID, userID, emoji string) (bool, error) {
+	message, _, err := s.reactionTarget(roomID, messageID, userID, emoji)
+	if err != nil {
+		return false, err
+	}
+
+	return s.reactionRepo.Remove(message.ID.String(), userID, emoji)
+}
+
+func (s *chatService) reactionTarget(roomID, messageID, userID, emoji string) (*models.Message, uuid.UUID, error) {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		return nil, uuid.Nil, ErrInvalidUserID
+	}
+
+	if strings.TrimSpace(emoji) == "" || len(emoji) > models.MaxEmojiLength {
+		return nil, uuid.Nil, ErrInvalidEmoji
+	}
+
+	message, err := s.findWritableMessage(roomID, messageID)
+	if err != nil {
+		return nil, uuid.Nil, err
+	}
+
+	if message.DeletedAt != nil {
+		return nil, uuid.Nil, ErrMessageDeleted
+	}
+
+	return message, userUUID, nil
+}
+
+func (s *chatService) attachReactions(messages []*models.Message, userID string) error {
+	ids := make([]uuid.UUID, len(messages))
+	for i, m := range messages {
+		ids[i] = m.ID
+	}
+
+	summaries, err := s.reactionRepo.Summaries(ids, userID)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range messages {
+		m.Reactions = summaries[m.ID]
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MarkRead moves the user's read position in the room up to messageID.
+// changed is false when they had already read that far.
+func (s *chatService) MarkRead(roomID, userID, messageID string) (*models.RoomParticipant, bool, error) {
+	if _, err := uuid.Parse(roomID); err != nil {
+		return nil, false, ErrInvalidRoomID
+	}
+
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, false, ErrInvalidUserID
+	}
+
+	participant, err := s.roomRepo.FindParticipant(roomID, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, false, ErrNotInRoom
+		}
+		return nil, false, err
+	}
+
+	message, err := s.findRoomMessage(roomID, messageID)
+	if err != nil {
+		return nil, false, err
+	}
+
+	now := time.Now()
+	participant.LastReadSeq = message.Seq
+	participant.LastReadMessageID = &message.ID
+	participant.LastReadAt = &now
+
+	changed, err := s.roomRepo.MarkRead(participant)
+	if err != nil {
+		return nil, false, err
+	}
+
+	return participant, changed, nil
+}
+
+func (s *chatService) GetUnreadCounts(userID string) ([]models.UnreadCount, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	counts, err := s.roomRepo.UnreadCounts(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if counts == nil {
+		counts = []models.UnreadCount{}
+	}
+	return counts, nil
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	minRoomNameLength = 3
+	maxRoomNameLength = 100
+)
+
+// UpdateRoom changes a room's name and description.
+func (s *chatService) UpdateRoom(roomID, userID string, req *models.UpdateRoomRequest) (*models.Room, error) {
+	updates := make(map[string]any)
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if len(name) < minRoomNameLength || len(name) > maxRoomNameLength {
+			return nil, ErrInvalidRoomName
+		}
+		updates["name"] = name
+	}
+	if req.Description != nil {
+		updates["description"] = *req.Description
+	}
+
+	if _, err := s.authorize(roomID, userID, permEditRoom); err != nil {
+		return nil, err
+	}
+
+	if len(updates) == 0 {
+		return s.findRoom(roomID)
+	}
+
+	return s.updateRoom(roomID, updates)
+}
+
+// SetRoomArchived archives or restores a room. Archived rooms keep their
+// history readable but take no new messages, edits, deletions or reactions.
+func (s *chatService) SetRoomArchived(roomID, userID string, archived bool) (*models.Room, error) {
+	if _, err := s.authorize(roomID, userID, permChangeSettings); err != nil {
+		return nil, err
+	}
+
+	var archivedAt *time.Time
+	if archived {
+		now := time.Now()
+		archivedAt = &now
+	}
+
+	return s.updateRoom(roomID, map[string]any{"archived_at": archivedAt})
+}
+
+// DeleteRoom removes the room and all of its history for good.
+func (s *chatService) DeleteRoom(roomID, userID string) error {
+	if _, err := s.authorize(roomID, userID, permDeleteRoom); err != nil {
+		return err
+	}
+
+	if err := s.roomRepo.Delete(roomID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrRoomNotFound
+		}
+		return err
+	}
+	return nil
+}
+
+// TransferOwnership hands the room to another participant; the previous
+// owner stays on as an admin. Both updated participants are returned.
+func (s *chatService) TransferOwnership(roomID, userID, targetID string) (*models.RoomParticipant, *models.RoomParticipant, error) {
+	targetUUID, err := uuid.Parse(targetID)
+	if err != nil {
+		return nil, nil, ErrInvalidUserID
+	}
+
+	owner, err := s.authorize(roomID, userID, permTransferOwnership)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if owner.UserID == targetUUID {
+		return nil, nil, ErrInvalidTransfer
+	}
+
+	target, err := s.findParticipant(roomID, targetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.roomRepo.TransferOwnership(roomID, owner.UserID, target.UserID); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, ErrParticipantNotFound
+		}
+		return nil, nil, err
+	}
+
+	owner.Role = models.RoleAdmin
+	target.Role = models.RoleOwner
+	return owner, target, nil
+}
+
+func (s *chatService) updateRoom(roomID string, updates map[string]any) (*models.Room, error) {
+	room, err := s.roomRepo.Update(roomID, updates)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRoomNotFound
+		}
+		return nil, err
+	}
+	return room, nil
+}
+
+// writableRoom loads a room that is about to change and refuses archived ones.
+func (s *chatService) writableRoom(roomID string) (*models.Room, error) {
+	room, err := s.findRoom(roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if room.ArchivedAt != nil {
+		return nil, ErrRoomArchived
+	}
+	return room, nil
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"html"
+	"strings"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/models"
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/repository"
+	"github.com/google/uuid"
+)
+
+var snippetMarker = strings.NewReplacer(
+	repository.SearchMatchStart, "<mark>",
+	repository.SearchMatchStop, "</mark>",
+)
+
+// SearchMessages finds messages matching query.Text in the rooms userID
+// participates in. Narrowing to a room the user is not in is an error rather
+// than an empty result.
+func (s *chatService) SearchMessages(userID string, query *models.SearchQuery) (*models.SearchPage, error) {
+	if _, err := uuid.Parse(userID); err != nil {
+		return nil, ErrInvalidUserID
+	}
+
+	query.Text = strings.TrimSpace(query.Text)
+	if query.Text == "" || len(query.Text) > models.MaxSearchQueryLength {
+		return nil, ErrInvalidSearch
+	}
+
+	if query.RoomID != "" {
+		if _, err := uuid.Parse(query.RoomID); err != nil {
+			return nil, ErrInvalidRoomID
+		}
+
+		isParticipant, err := s.roomRepo.IsParticipant(query.RoomID, userID)
+		if err != nil {
+			return nil, err
+		}
+		if !isParticipant {
+			return nil, ErrNotInRoom
+		}
+	}
+
+	if query.Offset < 0 {
+		query.Offset = 0
+	}
+
+	limit := query.Limit
+	if limit <= 0 || limit > 50 {
+		limit = 20
+	}
+
+	results, err := s.messageRepo.Search(userID, query, limit+1)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &models.SearchPage{Results: results}
+	if page.Results == nil {
+		page.Results = []*models.SearchResult{}
+	}
+	if len(results) > limit {
+		page.Results = results[:limit]
+		page.NextOffset = query.Offset + limit
+	}
+
+	for _, result := range page.Results {
+		result.Snippet = renderSnippet(result.Snippet)
+	}
+
+	return page, nil
+}
+
+// renderSnippet escapes the snippet and only then turns the match markers
+// into <mark> tags, so message content can never inject markup.
+func renderSnippet(snippet string) string {
+	return snippetMarker.Replace(html.EscapeString(snippet))
+}
@@ -0,0 +1,33 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/internal/repository"
+)
+
+func TestRenderSnippet(t *testing.T) {
+	start, stop := repository.SearchMatchStart, repository.SearchMatchStop
+
+	tests := []struct {
+		name    string
+		snippet string
+		want    string
+	}{
+		{name: "plain", snippet: "nothing special", want: "nothing special"},
+		{name: "match", snippet: "the " + start + "deploy" + stop + " failed", want: "the <mark>deploy</mark> failed"},
+		{name: "several matches", snippet: start + "a" + stop + " " + start + "b" + stop, want: "<mark>a</mark> <mark>b</mark>"},
+		{name: "markup is escaped", snippet: "<script>alert(1)</script>", want: "&lt;script&gt;alert(1)&lt;/script&gt;"},
+		{name: "literal mark tags are escaped", snippet: "<mark>fake</mark>", want: "&lt;mark&gt;fake&lt;/mark&gt;"},
+		{name: "escaped inside a match", snippet: start + "<b>" + stop, want: "<mark>&lt;b&gt;</mark>"},
+		{name: "quotes and ampersands", snippet: `"a" & 'b'`, want: "&#34;a&#34; &amp; &#39;b&#39;"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := renderSnippet(tt.snippet); got != tt.want {
+				t.Errorf("renderSnippet(%q) = %q, want %q", tt.snippet, got, tt.want)
+			}
+		})
+	}
+}
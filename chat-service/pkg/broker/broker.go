@@ -0,0 +1,84 @@
+package broker
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	ModeMemory  = "memory"
+	ModePubSub  = "pubsub"
+	ModeStreams = "streams"
+)
+
+// Broker carries messages between chat-service nodes on named topics.
+// redispkg.RedisPubSub and redispkg.RedisStreams implement it for multi-node
+// deployments; MemoryBroker covers a single process.
+type Broker interface {
+	Publish(ctx context.Context, topic, message string) error
+	Subscribe(ctx context.Context) Subscription
+}
+
+// Subscription receives what is published on the topics added to it until
+// the context it was opened with is cancelled. A node opens one and adds and
+// removes topics as rooms and users come and go, so it holds a single broker
+// connection however many of them it serves. Add and Remove never wait on the
+// broker, which lets the hub call them under its lock; they take effect in
+// the order they were made.
+type Subscription interface {
+	Add(topics ...string)
+	Remove(topics ...string)
+	Messages() <-chan Message
+}
+
+// Message is a payload together with the topic it was published on.
+type Message struct {
+	Topic   string
+	Payload string
+}
+
+// TopicChange is a topic added to or removed from a subscription.
+type TopicChange struct {
+	Topic string
+	Add   bool
+}
+
+// Changes queues topic changes for subscriptions that apply them on their
+// own goroutine. Push never blocks; Ready fires when there is something to
+// Take.
+type Changes struct {
+	mu      sync.Mutex
+	pending []TopicChange
+	ready   chan struct{}
+}
+
+func NewChanges() *Changes {
+	return &Changes{ready: make(chan struct{}, 1)}
+}
+
+func (c *Changes) Push(add bool, topics ...string) {
+	c.mu.Lock()
+	for _, topic := range topics {
+		c.pending = append(c.pending, TopicChange{Topic: topic, Add: add})
+	}
+	c.mu.Unlock()
+
+	select {
+	case c.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (c *Changes) Ready() <-chan struct{} {
+	return c.ready
+}
+
+// Take returns the queued changes in order and empties the queue.
+func (c *Changes) Take() []TopicChange {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	pending := c.pending
+	c.pending = nil
+	return pending
+}
@@ -0,0 +1,110 @@
+package broker
+
+import (
+	"context"
+	"sync"
+)
+
+const subscriberBufferSize = 100
+
+// MemoryBroker fans messages out to subscribers in the same process. Like
+// Redis pub/sub it does not queue for slow readers: a subscriber whose buffer
+// is full misses the message.
+type MemoryBroker struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[*memorySubscription]struct{}
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{
+		subscribers: make(map[string]map[*memorySubscription]struct{}),
+	}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, topic, message string) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	for sub := range b.subscribers[topic] {
+		select {
+		case sub.messages <- Message{Topic: topic, Payload: message}:
+		case <-ctx.Done():
+			return ctx.Err()
+		default:
+		}
+	}
+	return nil
+}
+
+func (b *MemoryBroker) Subscribe(ctx context.Context) Subscription {
+	sub := &memorySubscription{
+		broker:   b,
+		topics:   make(map[string]struct{}),
+		messages: make(chan Message, subscriberBufferSize),
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		b.mu.Lock()
+		for topic := range sub.topics {
+			b.drop(topic, sub)
+		}
+		sub.closed = true
+		close(sub.messages)
+		b.mu.Unlock()
+	}()
+
+	return sub
+}
+
+// drop must be called with b.mu held.
+func (b *MemoryBroker) drop(topic string, sub *memorySubscription) {
+	delete(b.subscribers[topic], sub)
+	if len(b.subscribers[topic]) == 0 {
+		delete(b.subscribers, topic)
+	}
+}
+
+// memorySubscription changes topics synchronously; with nothing to wait on
+// there is no reason to queue.
+type memorySubscription struct {
+	broker   *MemoryBroker
+	topics   map[string]struct{}
+	messages chan Message
+	closed   bool
+}
+
+func (s *memorySubscription) Add(topics ...string) {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	for _, topic := range topics {
+		if b.subscribers[topic] == nil {
+			b.subscribers[topic] = make(map[*memorySubscription]struct{})
+		}
+		b.subscribers[topic][s] = struct{}{}
+		s.topics[topic] = struct{}{}
+	}
+}
+
+func (s *memorySubscription) Remove(topics ...string) {
+	b := s.broker
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, topic := range topics {
+		if _, ok := s.topics[topic]; ok {
+			delete(s.topics, topic)
+			b.drop(topic, s)
+		}
+	}
+}
+
+func (s *memorySubscription) Messages() <-chan Message {
+	return s.messages
+}
@@ -0,0 +1,181 @@
+package broker
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryBrokerPublishSubscribe(t *testing.T) {
+	tests := []struct {
+		name         string
+		subscribed   []string
+		publishTopic string
+		want         []bool
+	}{
+		{
+			name:         "single subscriber",
+			subscribed:   []string{"chat.room.1"},
+			publishTopic: "chat.room.1",
+			want:         []bool{true},
+		},
+		{
+			name:         "every subscriber of the topic",
+			subscribed:   []string{"chat.room.1", "chat.room.1"},
+			publishTopic: "chat.room.1",
+			want:         []bool{true, true},
+		},
+		{
+			name:         "other topics are not delivered",
+			subscribed:   []string{"chat.room.1", "chat.room.2"},
+			publishTopic: "chat.room.2",
+			want:         []bool{false, true},
+		},
+		{
+			name:         "no subscribers",
+			publishTopic: "chat.room.1",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			b := NewMemoryBroker()
+			channels := make([]<-chan Message, len(tt.subscribed))
+			for i, topic := range tt.subscribed {
+				sub := b.Subscribe(ctx)
+				sub.Add(topic)
+				channels[i] = sub.Messages()
+			}
+
+			if err := b.Publish(ctx, tt.publishTopic, "hello"); err != nil {
+				t.Fatalf("Publish() error = %v", err)
+			}
+
+			for i, ch := range channels {
+				select {
+				case msg := <-ch:
+					want := Message{Topic: tt.publishTopic, Payload: "hello"}
+					if !tt.want[i] {
+						t.Errorf("subscriber %d got %+v, want nothing", i, msg)
+					} else if msg != want {
+						t.Errorf("subscriber %d got %+v, want %+v", i, msg, want)
+					}
+				default:
+					if tt.want[i] {
+						t.Errorf("subscriber %d got nothing, want %q", i, "hello")
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryBrokerSubscriptionTopics(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewMemoryBroker()
+	sub := b.Subscribe(ctx)
+	sub.Add("chat.room.1", "chat.user.1")
+
+	for _, topic := range []string{"chat.room.1", "chat.user.1", "chat.room.2"} {
+		if err := b.Publish(ctx, topic, "hello"); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+	if got := len(sub.Messages()); got != 2 {
+		t.Fatalf("received %d messages, want one per added topic", got)
+	}
+	for _, want := range []string{"chat.room.1", "chat.user.1"} {
+		if msg := <-sub.Messages(); msg.Topic != want {
+			t.Errorf("got message on %q, want %q", msg.Topic, want)
+		}
+	}
+
+	sub.Remove("chat.room.1")
+	if err := b.Publish(ctx, "chat.room.1", "hello"); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if got := len(sub.Messages()); got != 0 {
+		t.Errorf("received %d messages on a removed topic", got)
+	}
+}
+
+func TestMemoryBrokerUnsubscribe(t *testing.T) {
+	b := NewMemoryBroker()
+
+	subCtx, unsubscribe := context.WithCancel(context.Background())
+	sub := b.Subscribe(subCtx)
+	sub.Add("chat.room.1")
+	unsubscribe()
+
+	select {
+	case _, ok := <-sub.Messages():
+		if ok {
+			t.Fatal("received a message after unsubscribing")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("channel was not closed after unsubscribing")
+	}
+
+	// Adding to a closed subscription must not bring it back.
+	sub.Add("chat.room.2")
+
+	if err := b.Publish(context.Background(), "chat.room.1", "hello"); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if len(b.subscribers) != 0 {
+		t.Errorf("topics still have subscribers after the last one left: %v", b.subscribers)
+	}
+}
+
+func TestMemoryBrokerDropsWhenBufferFull(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	b := NewMemoryBroker()
+	sub := b.Subscribe(ctx)
+	sub.Add("chat.room.1")
+
+	for range subscriberBufferSize + 1 {
+		if err := b.Publish(ctx, "chat.room.1", "hello"); err != nil {
+			t.Fatalf("Publish() error = %v", err)
+		}
+	}
+
+	if got := len(sub.Messages()); got != subscriberBufferSize {
+		t.Errorf("buffered %d messages, want %d", got, subscriberBufferSize)
+	}
+}
+
+func TestChangesKeepOrder(t *testing.T) {
+	c := NewChanges()
+	c.Push(true, "a", "b")
+	c.Push(false, "a")
+
+	select {
+	case <-c.Ready():
+	default:
+		t.Fatal("Ready did not fire after Push")
+	}
+
+	want := []TopicChange{{Topic: "a", Add: true}, {Topic: "b", Add: true}, {Topic: "a"}}
+	got := c.Take()
+	if len(got) != len(want) {
+		t.Fatalf("Take() = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Take()[%d] = %v, want %v", i, got[i], want[i])
+		}
+	}
+	if rest := c.Take(); len(rest) != 0 {
+		t.Errorf("second Take() = %v, want nothing", rest)
+	}
+}
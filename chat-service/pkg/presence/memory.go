@@ -0,0 +1,105 @@
+package presence
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryTracker is the single-process counterpart of RedisTracker.
+type MemoryTracker struct {
+	mu          sync.Mutex
+	ttl         time.Duration
+	connections map[string]map[string]time.Time
+	statuses    map[string]string
+}
+
+func NewMemoryTracker(ttl time.Duration) *MemoryTracker {
+	return &MemoryTracker{
+		ttl:         ttl,
+		connections: make(map[string]map[string]time.Time),
+		statuses:    make(map[string]string),
+	}
+}
+
+func (t *MemoryTracker) Connect(ctx context.Context, userID, connID string) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	conns := t.liveConnections(userID)
+	if conns == nil {
+		conns = make(map[string]time.Time)
+		t.connections[userID] = conns
+	}
+	conns[connID] = time.Now().Add(t.ttl)
+
+	return len(conns) == 1, nil
+}
+
+func (t *MemoryTracker) Refresh(ctx context.Context, userID, connID string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	conns := t.connections[userID]
+	if _, ok := conns[connID]; ok {
+		conns[connID] = time.Now().Add(t.ttl)
+	}
+	return nil
+}
+
+func (t *MemoryTracker) Disconnect(ctx context.Context, userID, connID string) (bool, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	conns := t.liveConnections(userID)
+	if _, ok := conns[connID]; !ok {
+		return false, nil
+	}
+
+	delete(conns, connID)
+	if len(conns) > 0 {
+		return false, nil
+	}
+
+	delete(t.connections, userID)
+	delete(t.statuses, userID)
+	return true, nil
+}
+
+func (t *MemoryTracker) SetStatus(ctx context.Context, userID, status string) error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.statuses[userID] = status
+	return nil
+}
+
+func (t *MemoryTracker) Statuses(ctx context.Context, userIDs []string) (map[string]string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	result := make(map[string]string, len(userIDs))
+	for _, userID := range userIDs {
+		switch {
+		case len(t.liveConnections(userID)) == 0:
+			result[userID] = StatusOffline
+		case t.statuses[userID] == StatusAway:
+			result[userID] = StatusAway
+		default:
+			result[userID] = StatusOnline
+		}
+	}
+	return result, nil
+}
+
+// liveConnections drops expired connections and must be called with t.mu held.
+func (t *MemoryTracker) liveConnections(userID string) map[string]time.Time {
+	conns := t.connections[userID]
+	now := time.Now()
+	for connID, expiresAt := range conns {
+		if now.After(expiresAt) {
+			delete(conns, connID)
+		}
+	}
+	return conns
+}
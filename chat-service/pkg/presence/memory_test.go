@@ -0,0 +1,187 @@
+package presence
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestMemoryTrackerConnections(t *testing.T) {
+	type step struct {
+		op     string // connect, disconnect, refresh or status
+		connID string
+		status string
+		want   bool
+	}
+
+	tests := []struct {
+		name       string
+		steps      []step
+		wantStatus string
+	}{
+		{
+			name:       "first connection comes online",
+			steps:      []step{{op: "connect", connID: "a", want: true}},
+			wantStatus: StatusOnline,
+		},
+		{
+			name: "second connection is not first",
+			steps: []step{
+				{op: "connect", connID: "a", want: true},
+				{op: "connect", connID: "b", want: false},
+			},
+			wantStatus: StatusOnline,
+		},
+		{
+			name: "online until the last connection leaves",
+			steps: []step{
+				{op: "connect", connID: "a", want: true},
+				{op: "connect", connID: "b", want: false},
+				{op: "disconnect", connID: "a", want: false},
+			},
+			wantStatus: StatusOnline,
+		},
+		{
+			name: "last disconnect goes offline",
+			steps: []step{
+				{op: "connect", connID: "a", want: true},
+				{op: "disconnect", connID: "a", want: true},
+			},
+			wantStatus: StatusOffline,
+		},
+		{
+			name: "unknown connection is ignored",
+			steps: []step{
+				{op: "connect", connID: "a", want: true},
+				{op: "disconnect", connID: "b", want: false},
+			},
+			wantStatus: StatusOnline,
+		},
+		{
+			name: "away while connected",
+			steps: []step{
+				{op: "connect", connID: "a", want: true},
+				{op: "status", status: StatusAway},
+			},
+			wantStatus: StatusAway,
+		},
+		{
+			name: "away is cleared by going offline",
+			steps: []step{
+				{op: "connect", connID: "a", want: true},
+				{op: "status", status: StatusAway},
+				{op: "disconnect", connID: "a", want: true},
+				{op: "connect", connID: "b", want: true},
+			},
+			wantStatus: StatusOnline,
+		},
+		{
+			name: "refresh does not bring back a disconnected connection",
+			steps: []step{
+				{op: "connect", connID: "a", want: true},
+				{op: "connect", connID: "b", want: false},
+				{op: "disconnect", connID: "a", want: false},
+				{op: "refresh", connID: "a"},
+				{op: "disconnect", connID: "b", want: true},
+			},
+			wantStatus: StatusOffline,
+		},
+		{
+			name: "refresh after the last disconnect stays offline",
+			steps: []step{
+				{op: "connect", connID: "a", want: true},
+				{op: "disconnect", connID: "a", want: true},
+				{op: "refresh", connID: "a"},
+			},
+			wantStatus: StatusOffline,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			tracker := NewMemoryTracker(time.Minute)
+
+			for i, s := range tt.steps {
+				var (
+					got bool
+					err error
+				)
+				switch s.op {
+				case "connect":
+					got, err = tracker.Connect(ctx, "user", s.connID)
+				case "disconnect":
+					got, err = tracker.Disconnect(ctx, "user", s.connID)
+				case "refresh":
+					err = tracker.Refresh(ctx, "user", s.connID)
+				case "status":
+					err = tracker.SetStatus(ctx, "user", s.status)
+				}
+				if err != nil {
+					t.Fatalf("step %d (%s): error = %v", i, s.op, err)
+				}
+				if got != s.want {
+					t.Errorf("step %d (%s %s) = %v, want %v", i, s.op, s.connID, got, s.want)
+				}
+			}
+
+			statuses, err := tracker.Statuses(ctx, []string{"user"})
+			if err != nil {
+				t.Fatalf("Statuses() error = %v", err)
+			}
+			if statuses["user"] != tt.wantStatus {
+				t.Errorf("status = %q, want %q", statuses["user"], tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestMemoryTrackerExpiry(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewMemoryTracker(20 * time.Millisecond)
+
+	if _, err := tracker.Connect(ctx, "user", "a"); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	time.Sleep(40 * time.Millisecond)
+
+	statuses, err := tracker.Statuses(ctx, []string{"user", "stranger"})
+	if err != nil {
+		t.Fatalf("Statuses() error = %v", err)
+	}
+	for _, userID := range []string{"user", "stranger"} {
+		if statuses[userID] != StatusOffline {
+			t.Errorf("status of %s = %q, want %q", userID, statuses[userID], StatusOffline)
+		}
+	}
+
+	first, err := tracker.Connect(ctx, "user", "b")
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+	if !first {
+		t.Error("Connect() after expiry = false, want true")
+	}
+}
+
+func TestMemoryTrackerRefreshKeepsConnectionAlive(t *testing.T) {
+	ctx := context.Background()
+	tracker := NewMemoryTracker(200 * time.Millisecond)
+
+	if _, err := tracker.Connect(ctx, "user", "a"); err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	for range 3 {
+		time.Sleep(100 * time.Millisecond)
+		if err := tracker.Refresh(ctx, "user", "a"); err != nil {
+			t.Fatalf("Refresh() error = %v", err)
+		}
+	}
+
+	statuses, _ := tracker.Statuses(ctx, []string{"user"})
+	if statuses["user"] != StatusOnline {
+		t.Errorf("status = %q, want %q", statuses["user"], StatusOnline)
+	}
+}
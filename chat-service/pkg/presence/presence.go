@@ -0,0 +1,35 @@
+package presence
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	StatusOnline  = "online"
+	StatusAway    = "away"
+	StatusOffline = "offline"
+
+	// DefaultTTL outlives two client ping periods, so a connection is only
+	// considered gone after it misses more than one refresh.
+	DefaultTTL = 2 * time.Minute
+)
+
+// Tracker records which users have live connections. Each connection is
+// tracked on its own, so a user stays online until their last tab closes or
+// stops refreshing.
+type Tracker interface {
+	// Connect reports whether connID is the user's only live connection.
+	Connect(ctx context.Context, userID, connID string) (bool, error)
+	// Refresh extends a connection that is still tracked and never adds one,
+	// so a ping racing with Disconnect cannot bring the connection back.
+	Refresh(ctx context.Context, userID, connID string) error
+	// Disconnect reports whether the user has no live connections left.
+	Disconnect(ctx context.Context, userID, connID string) (bool, error)
+	SetStatus(ctx context.Context, userID, status string) error
+	Statuses(ctx context.Context, userIDs []string) (map[string]string, error)
+}
+
+func IsSettable(status string) bool {
+	return status == StatusOnline || status == StatusAway
+}
@@ -0,0 +1,127 @@
+package presence
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisTracker keeps a sorted set of connection IDs per user, scored by the
+// time each connection expires, plus an optional away status. Both keys carry
+// a TTL so users on a node that dies simply age out.
+type RedisTracker struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+func NewRedisTracker(client *redis.Client, ttl time.Duration) *RedisTracker {
+	return &RedisTracker{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+func (t *RedisTracker) Connect(ctx context.Context, userID, connID string) (bool, error) {
+	now := time.Now()
+	key := connectionsKey(userID)
+
+	var count *redis.IntCmd
+	_, err := t.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Unix(), 10))
+		pipe.ZAdd(ctx, key, redis.Z{Score: float64(now.Add(t.ttl).Unix()), Member: connID})
+		count = pipe.ZCard(ctx, key)
+		pipe.Expire(ctx, key, t.ttl)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return count.Val() == 1, nil
+}
+
+// Refresh uses ZADD XX so only connections still in the set are extended.
+func (t *RedisTracker) Refresh(ctx context.Context, userID, connID string) error {
+	expiresAt := time.Now().Add(t.ttl)
+
+	_, err := t.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.ZAddXX(ctx, connectionsKey(userID), redis.Z{Score: float64(expiresAt.Unix()), Member: connID})
+		pipe.Expire(ctx, connectionsKey(userID), t.ttl)
+		pipe.Expire(ctx, statusKey(userID), t.ttl)
+		return nil
+	})
+	return err
+}
+
+func (t *RedisTracker) Disconnect(ctx context.Context, userID, connID string) (bool, error) {
+	now := time.Now()
+	key := connectionsKey(userID)
+
+	var removed, count *redis.IntCmd
+	_, err := t.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		removed = pipe.ZRem(ctx, key, connID)
+		pipe.ZRemRangeByScore(ctx, key, "-inf", strconv.FormatInt(now.Unix(), 10))
+		count = pipe.ZCard(ctx, key)
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+
+	last := removed.Val() == 1 && count.Val() == 0
+	if last {
+		if err := t.client.Del(ctx, statusKey(userID)).Err(); err != nil {
+			return true, err
+		}
+	}
+	return last, nil
+}
+
+func (t *RedisTracker) SetStatus(ctx context.Context, userID, status string) error {
+	return t.client.Set(ctx, statusKey(userID), status, t.ttl).Err()
+}
+
+func (t *RedisTracker) Statuses(ctx context.Context, userIDs []string) (map[string]string, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	counts := make([]*redis.IntCmd, len(userIDs))
+	statuses := make([]*redis.StringCmd, len(userIDs))
+	_, err := t.client.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, userID := range userIDs {
+			counts[i] = pipe.ZCount(ctx, connectionsKey(userID), "("+now, "+inf")
+			statuses[i] = pipe.Get(ctx, statusKey(userID))
+		}
+		return nil
+	})
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(userIDs))
+	for i, userID := range userIDs {
+		if err := counts[i].Err(); err != nil {
+			return nil, err
+		}
+
+		switch {
+		case counts[i].Val() == 0:
+			result[userID] = StatusOffline
+		case statuses[i].Val() == StatusAway:
+			result[userID] = StatusAway
+		default:
+			result[userID] = StatusOnline
+		}
+	}
+	return result, nil
+}
+
+func connectionsKey(userID string) string {
+	return "presence:conns:" + userID
+}
+
+func statusKey(userID string) string {
+	return "presence:status:" + userID
+}
@@ -3,6 +3,7 @@ package redispkg
 import (
 	"context"
 
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/broker"
 	"github.com/dmehra2102/go-realtime-chat/shared/pkg/logger"
 	"github.com/redis/go-redis/v9"
 )
@@ -23,44 +24,87 @@ func (r *RedisPubSub) Publish(ctx context.Context, channel, message string) erro
 	return r.client.Publish(ctx, channel, message).Err()
 }
 
-func (r *RedisPubSub) Subscribe(ctx context.Context, channel string) <-chan string {
-	pubSub := r.client.Subscribe(ctx, channel)
-	msgChan := make(chan string, 100)
+// Subscribe opens one Redis connection for all of the node's channels.
+// go-redis remembers the channels it was asked for and subscribes to them
+// again whenever it reconnects.
+func (r *RedisPubSub) Subscribe(ctx context.Context) broker.Subscription {
+	sub := &pubSubSubscription{
+		pubSub:   r.client.Subscribe(ctx),
+		changes:  broker.NewChanges(),
+		messages: make(chan broker.Message, 100),
+		logger:   r.logger,
+	}
+
+	go sub.applyChanges(ctx)
+	go sub.receive(ctx)
+
+	return sub
+}
+
+type pubSubSubscription struct {
+	pubSub   *redis.PubSub
+	changes  *broker.Changes
+	messages chan broker.Message
+	logger   *logger.Logger
+}
+
+func (s *pubSubSubscription) Add(channels ...string) {
+	s.changes.Push(true, channels...)
+}
+
+func (s *pubSubSubscription) Remove(channels ...string) {
+	s.changes.Push(false, channels...)
+}
+
+func (s *pubSubSubscription) Messages() <-chan broker.Message {
+	return s.messages
+}
 
-	if _, err := pubSub.Receive(ctx); err != nil {
-		r.logger.Error("failed to subscribe to channel", "channel", channel, "error", err)
-		pubSub.Close()
-		closedChan := make(chan string)
-		close(closedChan)
-		return closedChan
+// applyChanges sends SUBSCRIBE and UNSUBSCRIBE for queued changes. A failed
+// command needs no retry: the channel set is already updated, and the
+// reconnect that follows the failure restores it.
+func (s *pubSubSubscription) applyChanges(ctx context.Context) {
+	defer s.pubSub.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changes.Ready():
+		}
+
+		for _, change := range s.changes.Take() {
+			var err error
+			if change.Add {
+				err = s.pubSub.Subscribe(ctx, change.Topic)
+			} else {
+				err = s.pubSub.Unsubscribe(ctx, change.Topic)
+			}
+			if err != nil && ctx.Err() == nil {
+				s.logger.Error("Redis subscription change failed", "channel", change.Topic, "subscribe", change.Add, "error", err)
+			}
+		}
 	}
+}
+
+func (s *pubSubSubscription) receive(ctx context.Context) {
+	defer close(s.messages)
 
-	go func() {
-		defer close(msgChan)
-		defer pubSub.Close()
+	msgChan := s.pubSub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-msgChan:
+			if !ok {
+				return
+			}
 
-		for {
 			select {
+			case s.messages <- broker.Message{Topic: msg.Channel, Payload: msg.Payload}:
 			case <-ctx.Done():
 				return
-			default:
-				msg, err := pubSub.ReceiveMessage(ctx)
-				if err != nil {
-					if ctx.Err() != nil {
-						return
-					}
-					r.logger.Error("Redis subscription error", "channel", channel, "error", err)
-					continue
-				}
-
-				select {
-				case msgChan <- msg.Payload:
-				case <-ctx.Done():
-					return
-				}
 			}
 		}
-	}()
-
-	return msgChan
+	}
 }
@@ -0,0 +1,316 @@
+package redispkg
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/dmehra2102/go-realtime-chat/chat-service/pkg/broker"
+	"github.com/dmehra2102/go-realtime-chat/shared/pkg/logger"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	streamPayloadField = "payload"
+	streamReadCount    = 100
+	// streamBlock also bounds how long a newly added stream waits before the
+	// reader includes it.
+	streamBlock      = time.Second
+	streamRetryDelay = time.Second
+	// streamIdleTTL expires streams nobody has published to for a while,
+	// together with their consumer groups, so rooms that went quiet and
+	// groups of retired nodes do not pile up in Redis.
+	streamIdleTTL = 7 * 24 * time.Hour
+)
+
+// RedisStreams delivers messages through Redis Streams. Every node reads
+// through its own consumer group, so each node sees every entry while Redis
+// remembers how far that node got; a node that loses its connection to Redis
+// picks up what it missed once it is back. That only holds within one
+// subscription: a stream added later starts at its current end, because
+// anything older is history that clients fetch with resume, not live
+// traffic. A stable node ID keeps restarts reusing the same group names.
+type RedisStreams struct {
+	client   *redis.Client
+	group    string
+	consumer string
+	maxLen   int64
+	logger   *logger.Logger
+}
+
+func NewRedisStreams(client *redis.Client, nodeID string, maxLen int64, logger *logger.Logger) *RedisStreams {
+	return &RedisStreams{
+		client:   client,
+		group:    "node:" + nodeID,
+		consumer: nodeID,
+		maxLen:   maxLen,
+		logger:   logger,
+	}
+}
+
+func (r *RedisStreams) Publish(ctx context.Context, stream, message string) error {
+	_, err := r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: stream,
+			MaxLen: r.maxLen,
+			Approx: true,
+			Values: map[string]any{streamPayloadField: message},
+		})
+		pipe.Expire(ctx, stream, streamIdleTTL)
+		return nil
+	})
+	return err
+}
+
+// Subscribe reads all of the node's streams with a single XREADGROUP, so a
+// node holds one blocked connection however many rooms and users it serves.
+func (r *RedisStreams) Subscribe(ctx context.Context) broker.Subscription {
+	sub := &streamSubscription{
+		streams:  r,
+		changes:  broker.NewChanges(),
+		messages: make(chan broker.Message, 100),
+		cursors:  make(map[string]*streamCursor),
+		wake:     make(chan struct{}, 1),
+	}
+
+	go sub.applyChanges(ctx)
+	go sub.read(ctx)
+
+	return sub
+}
+
+type streamSubscription struct {
+	streams  *RedisStreams
+	changes  *broker.Changes
+	messages chan broker.Message
+
+	mu      sync.Mutex
+	cursors map[string]*streamCursor
+	wake    chan struct{}
+}
+
+// streamCursor is the ID the next read of a stream starts from. Adding a
+// stream again replaces its cursor, which is how the reader tells entries of
+// the old group from those of the new one.
+type streamCursor struct {
+	stream string
+	lastID string
+}
+
+func (s *streamSubscription) Add(streams ...string) {
+	s.changes.Push(true, streams...)
+}
+
+func (s *streamSubscription) Remove(streams ...string) {
+	s.changes.Push(false, streams...)
+}
+
+func (s *streamSubscription) Messages() <-chan broker.Message {
+	return s.messages
+}
+
+// applyChanges starts a fresh group at the end of every added stream and
+// destroys the group of every removed one, so neither entries published
+// while the node was not listening nor entries left pending by an earlier
+// subscription are ever delivered.
+func (s *streamSubscription) applyChanges(ctx context.Context) {
+	r := s.streams
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.changes.Ready():
+		}
+
+		for _, change := range s.changes.Take() {
+			if change.Add {
+				if err := r.resetGroup(ctx, change.Topic); err != nil && ctx.Err() == nil {
+					r.logger.Error("failed to create consumer group", "stream", change.Topic, "error", err)
+				}
+
+				s.mu.Lock()
+				s.cursors[change.Topic] = &streamCursor{stream: change.Topic, lastID: ">"}
+				s.mu.Unlock()
+
+				select {
+				case s.wake <- struct{}{}:
+				default:
+				}
+				continue
+			}
+
+			s.mu.Lock()
+			delete(s.cursors, change.Topic)
+			s.mu.Unlock()
+
+			if err := r.client.XGroupDestroy(ctx, change.Topic, r.group).Err(); err != nil && ctx.Err() == nil {
+				r.logger.Warn("failed to destroy consumer group", "stream", change.Topic, "error", err)
+			}
+		}
+	}
+}
+
+func (s *streamSubscription) read(ctx context.Context) {
+	r := s.streams
+	defer close(s.messages)
+
+	for {
+		cursors := s.snapshot()
+		if len(cursors) == 0 {
+			select {
+			case <-s.wake:
+				continue
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		args := make([]string, 0, 2*len(cursors))
+		for _, c := range cursors {
+			args = append(args, c.stream)
+		}
+		for _, c := range cursors {
+			args = append(args, c.lastID)
+		}
+
+		streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    r.group,
+			Consumer: r.consumer,
+			Streams:  args,
+			Count:    streamReadCount,
+			Block:    streamBlock,
+		}).Result()
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			if errors.Is(err, redis.Nil) {
+				continue
+			}
+
+			r.logger.Error("Redis stream read error", "error", err)
+			if strings.HasPrefix(err.Error(), "NOGROUP") {
+				// One missing group fails the whole read, and the error
+				// does not reliably say which.
+				for _, c := range cursors {
+					if err := r.ensureGroup(ctx, c.stream); err != nil && ctx.Err() == nil {
+						r.logger.Error("failed to recreate consumer group", "stream", c.stream, "error", err)
+					}
+				}
+			}
+
+			// Anything read before the failure but not yet acked is still
+			// pending; pick it up again once we are back.
+			for _, c := range cursors {
+				if c.lastID == ">" {
+					c.lastID = "0"
+				}
+			}
+
+			select {
+			case <-time.After(streamRetryDelay):
+			case <-ctx.Done():
+				return
+			}
+			continue
+		}
+
+		read := make(map[string]redis.XStream, len(streams))
+		for _, stream := range streams {
+			read[stream.Stream] = stream
+		}
+
+		for _, c := range cursors {
+			if !s.deliver(ctx, c, read[c.stream].Messages) {
+				return
+			}
+		}
+	}
+}
+
+// deliver hands a stream's entries to the subscriber and acks them. Entries
+// of a stream that was removed or added again during the read are dropped.
+// It returns false once ctx is cancelled.
+func (s *streamSubscription) deliver(ctx context.Context, c *streamCursor, entries []redis.XMessage) bool {
+	r := s.streams
+
+	if !s.current(c) {
+		return true
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		payload, _ := entry.Values[streamPayloadField].(string)
+		select {
+		case s.messages <- broker.Message{Topic: c.stream, Payload: payload}:
+		case <-ctx.Done():
+			return false
+		}
+		ids = append(ids, entry.ID)
+	}
+
+	if len(ids) > 0 {
+		if err := r.client.XAck(ctx, c.stream, r.group, ids...).Err(); err != nil && ctx.Err() == nil {
+			r.logger.Error("failed to ack stream entries", "stream", c.stream, "count", len(ids), "error", err)
+		}
+	}
+
+	// A cursor other than ">" walks the entries still pending from before a
+	// read error; once it runs dry the stream is caught up.
+	if c.lastID != ">" {
+		if len(ids) == 0 {
+			c.lastID = ">"
+		} else {
+			c.lastID = ids[len(ids)-1]
+		}
+	}
+
+	return true
+}
+
+// snapshot returns the cursors to read. Only the reader moves a cursor, so
+// it can use them after the lock is released.
+func (s *streamSubscription) snapshot() []*streamCursor {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cursors := make([]*streamCursor, 0, len(s.cursors))
+	for _, c := range s.cursors {
+		cursors = append(cursors, c)
+	}
+	return cursors
+}
+
+func (s *streamSubscription) current(c *streamCursor) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.cursors[c.stream] == c
+}
+
+// resetGroup replaces the node's consumer group with one at the current end
+// of the stream, creating the stream if it does not exist. Destroying fails
+// harmlessly when there is no stream yet, so only the create counts.
+func (r *RedisStreams) resetGroup(ctx context.Context, stream string) error {
+	var create *redis.StatusCmd
+	_, _ = r.client.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.XGroupDestroy(ctx, stream, r.group)
+		create = pipe.XGroupCreateMkStream(ctx, stream, r.group, "$")
+		return nil
+	})
+	return create.Err()
+}
+
+// ensureGroup creates the node's consumer group at the current end of the
+// stream; an existing group keeps its position. A stream that expired is
+// recreated empty.
+func (r *RedisStreams) ensureGroup(ctx context.Context, stream string) error {
+	err := r.client.XGroupCreateMkStream(ctx, stream, r.group, "$").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return err
+	}
+	return nil
+}
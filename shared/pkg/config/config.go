@@ -1,18 +1,27 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"strconv"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 type Config struct {
-	AuthServicePort string
-	ChatServicePort string
-	RedisAddr       string
-	RedisPassword   string
-	JWTSecret       string
-	Database        DatabaseConfig
+	AuthServicePort       string
+	ChatServicePort       string
+	RedisAddr             string
+	RedisPassword         string
+	JWTSecret             string
+	NodeID                string
+	BrokerMode            string
+	StreamMaxLen          int64
+	MessageRetentionGrace time.Duration
+	MaxPinsPerRoom        int
+	Database              DatabaseConfig
 }
 
 type DatabaseConfig struct {
@@ -36,23 +45,48 @@ func (d DatabaseConfig) MigrationDSN() string {
 }
 
 func LoadConfig() *Config {
+	brokerMode := getEnv("BROKER_MODE", "pubsub")
+
+	// Streams consumer groups are named after the node, so in that mode the
+	// ID has to be set explicitly and survive restarts; see Validate.
+	nodeID := os.Getenv("NODE_ID")
+	if nodeID == "" && brokerMode != "streams" {
+		nodeID = uuid.NewString()
+	}
+
 	return &Config{
-		AuthServicePort: getEnv("AUTH_SERVICE_PORT", "8001"),
-		ChatServicePort: getEnv("CHAT_SERVICE_PORT", "8002"),
-		RedisAddr:       getEnv("REDIS_ADDR", "localhost:6379"),
-		RedisPassword:   getEnv("REDIS_PASSWORD", ""),
-		JWTSecret:       getEnv("JWT_SECRET", "secret-key-for-development"),
+		AuthServicePort:       getEnv("AUTH_SERVICE_PORT", "8001"),
+		ChatServicePort:       getEnv("CHAT_SERVICE_PORT", "8002"),
+		RedisAddr:             getEnv("REDIS_ADDR", "localhost:6379"),
+		RedisPassword:         getEnv("REDIS_PASSWORD", ""),
+		JWTSecret:             getEnv("JWT_SECRET", "secret-key-for-development"),
+		NodeID:                nodeID,
+		BrokerMode:            brokerMode,
+		StreamMaxLen:          int64(parseIntOrDefault("STREAM_MAX_LEN", 10000)),
+		MessageRetentionGrace: parseDurationOrDefault("MESSAGE_RETENTION_GRACE", 30*24*time.Hour),
+		MaxPinsPerRoom:        parseIntOrDefault("MAX_PINS_PER_ROOM", 50),
 		Database: DatabaseConfig{
 			Host:     getEnv("DB_HOST", "localhost"),
 			SSLMode:  getEnv("DB_SSL_MODE", "disable"),
 			User:     getEnv("DB_USER", "hruser"),
 			Password: getEnv("DB_PASSWORD", "hrpassword"),
 			Name:     getEnv("DB_NAME", "hrmanagement"),
-			Port:     parseIntOrDefault("DB_PORT"),
+			Port:     parseIntOrDefault("DB_PORT", 0),
 		},
 	}
 }
 
+// Validate reports settings chat-service cannot run with.
+func (c *Config) Validate() error {
+	if c.BrokerMode == "streams" && c.NodeID == "" {
+		return errors.New("NODE_ID must be set to a stable value when BROKER_MODE=streams")
+	}
+	if c.MaxPinsPerRoom < 1 {
+		return fmt.Errorf("MAX_PINS_PER_ROOM must be at least 1, got %d", c.MaxPinsPerRoom)
+	}
+	return nil
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -61,10 +95,18 @@ func getEnv(key, defaultValue string) string {
 	return value
 }
 
-func parseIntOrDefault(key string) int {
+func parseIntOrDefault(key string, defaultValue int) int {
 	value := os.Getenv(key)
 	if i, err := strconv.Atoi(value); err == nil {
 		return i
 	}
-	return 0
+	return defaultValue
+}
+
+func parseDurationOrDefault(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if d, err := time.ParseDuration(value); err == nil {
+		return d
+	}
+	return defaultValue
 }